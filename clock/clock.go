@@ -2,8 +2,6 @@ package clock
 
 import (
 	"context"
-	_ "embed"
-	"encoding/json"
 	"fmt"
 	"image"
 	"image/color"
@@ -14,52 +12,76 @@ import (
 
 	"github.com/g-wilson/led/config"
 	"github.com/g-wilson/led/internal/airmatters"
+	"github.com/g-wilson/led/internal/bitmapfont"
+	"github.com/g-wilson/led/internal/brightness"
 	"github.com/g-wilson/led/internal/calendar"
+	"github.com/g-wilson/led/internal/calibration"
 	"github.com/g-wilson/led/internal/diagnostics"
+	"github.com/g-wilson/led/internal/framestreamer"
 	"github.com/g-wilson/led/internal/hamediaplayer"
 	"github.com/g-wilson/led/internal/hasensors"
 	"github.com/g-wilson/led/internal/homeassistant"
+	"github.com/g-wilson/led/internal/sprite"
+	"github.com/g-wilson/led/internal/theme"
 	"github.com/g-wilson/led/internal/tomorrowio"
 	"github.com/g-wilson/led/internal/weather"
 
-	"github.com/toelsiba/fopix"
 	"golang.org/x/image/draw"
 )
 
-//go:embed fonts/tom-thumb-new.json
-var fontSource []byte
-
-type page func(c *image.RGBA) error
-
 type ClockRenderer struct {
-	font         *fopix.Drawer
-	weather      *weather.Agent
-	diagnostics  *diagnostics.Agent
-	sensors      *hasensors.Agent
-	mediaPlayer  *hamediaplayer.Agent
-	airQuality   *airmatters.Agent
-	location     *time.Location
-	pages        []page
-	currentPage  atomic.Int32
-	pageInterval time.Duration
-	debug        bool
+	fonts           map[string]*bitmapfont.Chain
+	font            bitmapfont.Face // the default font, used unless a page or the panel size picks another
+	bodyFont        bitmapfont.Face // the font for panels with room for bigger text
+	weatherIcons    *sprite.Sheet
+	weather         *weather.Agent
+	diagnostics     *diagnostics.Agent
+	sensors         *hasensors.Agent
+	mediaPlayer     *hamediaplayer.Agent
+	airQuality      *airmatters.Agent
+	location        *time.Location
+	night           nightSettings
+	themes          *themeSettings
+	frameTheme      *theme.Theme // the theme of the frame being drawn, owned by the rendering goroutine
+	brightness      *brightness.Controller
+	calibration     *calibration.Calibration
+	ditherFrametime int64 // ms between frames sent while dithering, 0 when not dithering
+	pages           []rotationPage
+	currentPage     atomic.Int32
+	pageSteps       atomic.Uint64 // counts the rotation's steps, so notifications can wait for the next page
+	paused          atomic.Bool
+	jump            chan int // page indexes to switch to, received by the rotation goroutine
+	stopped         <-chan struct{}
+	notifications   notificationQueue
+	activePage      int // the page last drawn, owned by the rendering goroutine, -1 before the first frame
+	transition      transitionState
+	pageInterval    time.Duration
+	debug           bool
 }
 
 func New(ctx context.Context, cfg *config.Settings) (*ClockRenderer, error) {
+	playlist, err := LoadPlaylist(cfg.PlaylistFile)
+	if err != nil {
+		return nil, err
+	}
+
 	if err := calendar.Load(cfg.CalendarFiles); err != nil {
 		return nil, fmt.Errorf("error loading calendar: %w", err)
 	}
 
-	fontInfo := fopix.FontInfo{}
-	err := json.Unmarshal(fontSource, &fontInfo)
+	fonts, err := loadFonts(cfg.FontFiles, cfg.FontDir, cfg.FontFallbacks)
 	if err != nil {
-		return nil, fmt.Errorf("error loading font info file: %w", err)
+		return nil, err
+	}
+	bodyFont, ok := fonts[cfg.BodyFont]
+	if !ok {
+		return nil, fmt.Errorf("invalid BODY_FONT: unknown font %q", cfg.BodyFont)
 	}
-	font, err := fopix.NewDrawer(fontInfo)
+
+	weatherIcons, err := loadWeatherIcons()
 	if err != nil {
-		return nil, fmt.Errorf("error creating font drawer: %w", err)
+		return nil, err
 	}
-	font.SetScale(1)
 
 	tomorrowIoClient := tomorrowio.New(cfg.TomorrowIOAPIKey, nil)
 	weatherAgent, err := weather.New(ctx, tomorrowIoClient, weather.AgentOptions{
@@ -81,26 +103,78 @@ func New(ctx context.Context, cfg *config.Settings) (*ClockRenderer, error) {
 		return nil, fmt.Errorf("cannot determine timezone: %w", err)
 	}
 
+	night, err := parseNightSettings(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	themes, err := loadThemes(cfg)
+	if err != nil {
+		return nil, err
+	}
+
 	r := &ClockRenderer{
-		font:         font,
+		fonts:        fonts,
+		font:         fonts[FontDefault],
+		bodyFont:     bodyFont,
+		weatherIcons: weatherIcons,
 		weather:      weatherAgent,
 		diagnostics:  diagAgent,
 		location:     location,
+		night:        night,
+		themes:       themes,
+		frameTheme:   themes.day,
+		activePage:   -1,
+		jump:         make(chan int),
+		stopped:      ctx.Done(),
 		pageInterval: 5 * time.Second,
 		debug:        cfg.Debug,
 	}
 
-	// Phase 1: static pages
-	r.pages = []page{
-		r.renderToday,
-		r.renderTomorrow,
-		r.renderDaylight,
-		r.renderMoon,
-		r.renderCountdown,
-		r.renderDiag,
+	// the controller is always created so brightness can be set at runtime, but
+	// only follows a curve if one is configured
+	var curve brightness.Curve
+	if cfg.BrightnessCurve != "" {
+		curve, err = brightness.ParseCurve(cfg.BrightnessCurve)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BRIGHTNESS_CURVE: %w", err)
+		}
+	}
+	r.brightness, err = brightness.New(brightness.Options{
+		Curve:    curve,
+		Min:      cfg.BrightnessMin,
+		Max:      cfg.BrightnessMax,
+		Sun:      r,
+		Location: location,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error creating brightness controller: %w", err)
+	}
+
+	// dithering needs the brightness applied at full precision, so the calibration
+	// takes it over from the brightness stage
+	calibrationOpts := calibration.Options{
+		Gamma: cfg.CalibrationGamma,
+		Gains: cfg.CalibrationGains,
+		Floor: cfg.CalibrationFloor,
+	}
+	if cfg.Dither {
+		if cfg.DitherFPS < 1 || cfg.DitherFPS > 120 {
+			return nil, fmt.Errorf("invalid DITHER_FPS %d: expected 1 to 120", cfg.DitherFPS)
+		}
+		calibrationOpts.Dither = true
+		calibrationOpts.Brightness = r.brightness
+		r.ditherFrametime = 1000 / int64(cfg.DitherFPS)
+	}
+	r.calibration, err = calibration.New(calibrationOpts)
+	if err != nil {
+		return nil, fmt.Errorf("invalid colour calibration: %w", err)
 	}
 
-	// Phase 2: dynamic area pages (skipped entirely if HA settings not provided)
+	// Optional agents: each is skipped entirely if its settings are not provided,
+	// and any playlist pages depending on it are left out of the rotation.
+
+	// Home Assistant sensors, used by the area pages
 	if cfg.HAURL != "" && cfg.HAToken != "" && len(cfg.HASensors) > 0 {
 		haClient := homeassistant.New(cfg.HAURL, cfg.HAToken, nil)
 		sensorsAgent, err := hasensors.New(ctx, haClient, cfg.HASensors)
@@ -108,15 +182,10 @@ func New(ctx context.Context, cfg *config.Settings) (*ClockRenderer, error) {
 			log.Printf("sensors agent unavailable, skipping area pages: %v", err)
 		} else {
 			r.sensors = sensorsAgent
-			for _, areaName := range sensorsAgent.GetAreas() {
-				r.pages = append(r.pages, func(c *image.RGBA) error {
-					return r.renderArea(c, areaName)
-				})
-			}
 		}
 	}
 
-	// Phase 3: air quality page (skipped if API key not provided)
+	// Air quality, used by the air quality page
 	if cfg.AirMattersAPIKey != "" {
 		amClient := airmatters.New(cfg.AirMattersAPIKey, nil)
 		amAgent, err := airmatters.NewAgent(ctx, amClient, airmatters.AgentOptions{
@@ -128,11 +197,10 @@ func New(ctx context.Context, cfg *config.Settings) (*ClockRenderer, error) {
 			log.Printf("air quality agent unavailable, skipping air quality page: %v", err)
 		} else {
 			r.airQuality = amAgent
-			r.pages = append(r.pages, r.renderAirQuality)
 		}
 	}
 
-	// Phase 4: media player page (skipped if HA settings or player list not provided)
+	// Media players, used by the now playing page
 	if cfg.HAURL != "" && cfg.HAToken != "" && len(cfg.HAMediaPlayers) > 0 {
 		haClient := homeassistant.New(cfg.HAURL, cfg.HAToken, nil)
 		mediaAgent, err := hamediaplayer.New(ctx, haClient, cfg.HAMediaPlayers)
@@ -140,10 +208,14 @@ func New(ctx context.Context, cfg *config.Settings) (*ClockRenderer, error) {
 			log.Printf("media player agent unavailable, skipping now playing page: %v", err)
 		} else {
 			r.mediaPlayer = mediaAgent
-			r.pages = append(r.pages, r.renderNowPlaying)
 		}
 	}
 
+	r.pages, err = r.buildPages(ctx, playlist)
+	if err != nil {
+		return nil, err
+	}
+
 	// start the page iterator to continuously tick through pages in the background.
 	r.startPageIterator(ctx)
 
@@ -152,59 +224,241 @@ func New(ctx context.Context, cfg *config.Settings) (*ClockRenderer, error) {
 
 // DrawFrame renders the current clock display into the provided target buffer.
 func (r *ClockRenderer) DrawFrame(c *image.RGBA) error {
+	now := time.Now()
+	r.frameTheme = r.currentTheme(now)
+
+	if err := r.drawFrame(c, now); err != nil {
+		return err
+	}
+
+	// tinted themes recolour everything, images included
+	r.frameTheme.Apply(c)
+
+	return nil
+}
+
+func (r *ClockRenderer) drawFrame(c *image.RGBA, now time.Time) error {
 	// clear the image to black as a background for the page
 	draw.Draw(c, c.Bounds(), &image.Uniform{color.Black}, image.Point{}, draw.Src)
 
-	// if it's overnight, don't render anything
-	if r.isCurrentlyOvernight() && !r.debug {
+	current := int(r.currentPage.Load())
+	night := r.nightMode(now)
+
+	// notifications take over the screen, except banners which are drawn over the page
+	n, notifying := r.notifications.onScreen(now, r.pageSteps.Load(), night != NightModeOff)
+	if notifying && (n.Style != NotificationBanner || night != NightModeOff) {
+		r.drawNotification(c, n, now)
+		return nil
+	}
+
+	// overnight, show as little as we've been asked to
+	switch night {
+	case NightModeBlank:
+		return nil
+	case NightModeClock:
+		r.drawNightClock(c, r.FrameTheme().Text)
+		return nil
+	case NightModeDim:
+		r.drawNightClock(c, colourNightDim)
 		return nil
+	case NightModePages:
+		// the rotation moves on to a night page at its next step, until then just show the time
+		if !r.allowedAtNight(r.pages[current]) {
+			r.drawNightClock(c, r.FrameTheme().Text)
+			return nil
+		}
+	}
+
+	// page content from the current page, animating between pages when it changes
+	if current != r.activePage {
+		r.changePage(current, now)
+	}
+	if r.transition.active {
+		if err := r.drawTransition(c, now); err != nil {
+			return err
+		}
+	} else if err := r.pages[r.activePage].Draw(c); err != nil {
+		return err
 	}
 
-	// all pages - clock
-	r.addText(c, image.Point{X: 0, Y: -1}, r.getTimeString(), color.RGBA{200, 200, 200, 255})
+	if notifying {
+		r.drawBanner(c, n)
+	}
+
+	// all pages but full screen ones - clock, drawn over the top so it stays put during transitions
+	if !r.pages[r.activePage].Info().FullScreen {
+		r.DrawText(c, c.Bounds().Min.Add(image.Pt(0, -1)), r.getTimeString(), r.FrameTheme().Text)
+	}
+
+	return nil
+}
+
+// changePage activates the next page and starts the transition to it, letting the
+// outgoing page know when it has left the screen.
+func (r *ClockRenderer) changePage(next int, now time.Time) {
+	prev := r.activePage
+	if r.transition.active {
+		// a transition was interrupted, so its outgoing page is already gone
+		if r.transition.from != next {
+			r.pages[r.transition.from].Deactivate()
+		}
+		r.transition.active = false
+	}
+
+	r.pages[next].Activate()
+	r.activePage = next
+
+	if prev < 0 {
+		return
+	}
+	if !r.pages[next].transition.animated() {
+		r.pages[prev].Deactivate()
+		return
+	}
 
-	// page content from the current page
-	return r.pages[r.currentPage.Load()](c)
+	r.transition.active = true
+	r.transition.from = prev
+	r.transition.transition = r.pages[next].transition
+	r.transition.startedAt = now
+}
+
+// drawTransition renders the outgoing and incoming pages offscreen and blends them
+// into the canvas, finishing the transition once it has run its course.
+func (r *ClockRenderer) drawTransition(c *image.RGBA, now time.Time) error {
+	t := &r.transition
+	progress := t.progress(now)
+
+	from, to := t.buffers(c.Bounds())
+	if err := r.pages[t.from].Draw(from); err != nil {
+		return err
+	}
+	if err := r.pages[r.activePage].Draw(to); err != nil {
+		return err
+	}
+	composeTransition(c, from, to, t.transition.Style, progress)
+
+	if progress >= 1 {
+		r.pages[t.from].Deactivate()
+		t.active = false
+	}
+
+	return nil
+}
+
+// FrametimeMs tells the framestreamer how quickly to render: fast while a transition
+// or notification is animating, otherwise as often as the current page asks to be refreshed.
+func (r *ClockRenderer) FrametimeMs() int64 {
+	if r.transition.active {
+		return framestreamer.ThirtyFPS
+	}
+	if r.notifications.active() {
+		return marqueeRefresh.Milliseconds()
+	}
+	if r.activePage >= 0 {
+		if refresh := r.pages[r.activePage].Info().Refresh; refresh > 0 {
+			return refresh.Milliseconds()
+		}
+	}
+	return 0
 }
 
-// startPageIterator kicks off a goroutine ticking continuously through
-// the length of the pages array, updating the current page each time
+// startPageIterator kicks off a goroutine stepping continuously through the pages,
+// holding each one on screen for its own duration and skipping any page which
+// has nothing to show.
 func (r *ClockRenderer) startPageIterator(ctx context.Context) {
+	// start on the first page with something to show
+	i := r.nextVisiblePage(len(r.pages) - 1)
+	r.currentPage.Store(int32(i))
+
 	go func() {
-		i := int32(0)
-		ticker := time.NewTicker(r.pageInterval)
-		defer ticker.Stop()
+		timer := time.NewTimer(r.pages[i].duration)
+		defer timer.Stop()
 		for {
 			select {
 			case <-ctx.Done():
 				return
-			case <-ticker.C:
-				i++
-				if int(i) > len(r.pages)-1 {
-					i = 0
+			case next := <-r.jump:
+				r.pageSteps.Add(1)
+				i = next
+				r.currentPage.Store(int32(i))
+				timer.Reset(r.pages[i].duration)
+			case <-timer.C:
+				if r.paused.Load() || r.notifications.holding() {
+					// stay put, giving the page its full time back once we move on
+					timer.Reset(r.pages[i].duration)
+					continue
 				}
-				r.currentPage.Store(i)
+				r.pageSteps.Add(1)
+				i = r.nextVisiblePage(i)
+				r.currentPage.Store(int32(i))
+				timer.Reset(r.pages[i].duration)
 			}
 		}
 	}()
 }
 
-func (r *ClockRenderer) addText(c *image.RGBA, pos image.Point, text string, col color.RGBA) {
-	r.font.SetColor(col)
-	r.font.DrawText(c, pos, text)
+// nextVisiblePage returns the index of the first ready page after the given one,
+// wrapping around the end of the rotation. If no other page is ready, the given
+// page is returned so the display stays where it is.
+func (r *ClockRenderer) nextVisiblePage(current int) int {
+	nightPages := r.nightMode(time.Now()) == NightModePages
+	for step := 1; step <= len(r.pages); step++ {
+		i := (current + step) % len(r.pages)
+		if nightPages && !r.allowedAtNight(r.pages[i]) {
+			continue
+		}
+		if r.pages[i].Ready() {
+			return i
+		}
+	}
+	return current
+}
+
+// DrawText draws text in the canvas's text font with the top-left corner of its line at pos.
+func (r *ClockRenderer) DrawText(c *image.RGBA, pos image.Point, text string, col color.RGBA) {
+	r.Layout(c).Font.Draw(c, pos, text, col)
 }
 
-func (r *ClockRenderer) getTimeString() string {
-	return time.Now().UTC().In(r.location).Format("15:04 Mon 2 Jan")
+// DrawTextIn draws text in the canvas's text font within a rectangle, placed, wrapped
+// and shortened to fit as the layout says, and clipped to the rectangle.
+func (r *ClockRenderer) DrawTextIn(c *image.RGBA, rect image.Rectangle, text string, col color.RGBA, layout bitmapfont.Layout) {
+	layout.Draw(c, r.Layout(c).Font, rect, text, col)
 }
 
-func (r *ClockRenderer) isCurrentlyOvernight() bool {
-	now := time.Now().In(r.location)
-	year, month, day := now.Date()
-	today8pm := time.Date(year, month, day, 20, 0, 0, 0, r.location)
-	today6am := time.Date(year, month, day, 6, 0, 0, 0, r.location)
+// drawTextClipped draws text in the given font like DrawText, but only within the clip rectangle.
+func (r *ClockRenderer) drawTextClipped(c *image.RGBA, font bitmapfont.Face, pos image.Point, text string, col color.RGBA, clip image.Rectangle) {
+	font.Draw(bitmapfont.Clip(c, clip), pos, text, col)
+}
 
-	return today8pm.Before(now) || today6am.After(now)
+// Filters returns the stages each output should apply to frames after they are drawn,
+// such as the brightness curve. Calibration comes last, so it corrects the frame as
+// it will be shown.
+func (r *ClockRenderer) Filters() []framestreamer.Filter {
+	if r.ditherFrametime > 0 {
+		return []framestreamer.Filter{r.calibration}
+	}
+	return []framestreamer.Filter{r.brightness, r.calibration}
+}
+
+// RepeatMs tells the framestreamer how often to send frames between those drawn,
+// which is only needed while dithering.
+func (r *ClockRenderer) RepeatMs() int64 {
+	return r.ditherFrametime
+}
+
+// Calibration returns the colour calibration stage of Filters, so it can be turned
+// off to compare with the raw frames.
+func (r *ClockRenderer) Calibration() *calibration.Calibration {
+	return r.calibration
+}
+
+// Location returns the timezone the clock is displayed in.
+func (r *ClockRenderer) Location() *time.Location {
+	return r.location
+}
+
+func (r *ClockRenderer) getTimeString() string {
+	return time.Now().UTC().In(r.location).Format("15:04 Mon 2 Jan")
 }
 
 func formatShortDuration(d time.Duration) string {
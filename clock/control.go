@@ -0,0 +1,215 @@
+package clock
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/g-wilson/led/internal/hasensors"
+)
+
+// The methods in this file let other goroutines, such as the HTTP API, inspect and
+// steer the running clock. They are all safe for concurrent use.
+
+// PageStatus describes a page in the rotation.
+type PageStatus struct {
+	Index    int           `json:"index"`
+	ID       string        `json:"id"`
+	Name     string        `json:"name"`
+	Duration time.Duration `json:"duration"`
+	Ready    bool          `json:"ready"`
+	Current  bool          `json:"current"`
+}
+
+// Pages lists the pages in the rotation, in order.
+func (r *ClockRenderer) Pages() []PageStatus {
+	current := int(r.currentPage.Load())
+
+	out := make([]PageStatus, len(r.pages))
+	for i, p := range r.pages {
+		out[i] = PageStatus{
+			Index:    i,
+			ID:       p.id,
+			Name:     p.name,
+			Duration: p.duration,
+			Ready:    p.Ready(),
+			Current:  i == current,
+		}
+	}
+	return out
+}
+
+// CurrentPage returns the page on screen, or about to be.
+func (r *ClockRenderer) CurrentPage() PageStatus {
+	return r.Pages()[r.currentPage.Load()]
+}
+
+// ShowPage switches straight to a page, given its index in the rotation, its name
+// or its playlist ID. An ID shared by several pages selects the first of them.
+// The page is shown even if it has nothing ready to show, and stays for its usual
+// duration, or until the rotation is resumed if it is paused.
+func (r *ClockRenderer) ShowPage(page string) error {
+	i, err := r.findPage(page)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case r.jump <- i:
+		return nil
+	case <-r.stopped:
+		return fmt.Errorf("clock has stopped")
+	}
+}
+
+func (r *ClockRenderer) findPage(page string) (int, error) {
+	if i, err := strconv.Atoi(page); err == nil {
+		if i < 0 || i >= len(r.pages) {
+			return 0, fmt.Errorf("page index %d out of range", i)
+		}
+		return i, nil
+	}
+	for i, p := range r.pages {
+		if p.name == page {
+			return i, nil
+		}
+	}
+	for i, p := range r.pages {
+		if p.id == page {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("no page %q in the rotation", page)
+}
+
+// PauseRotation holds the current page on screen until ResumeRotation is called.
+func (r *ClockRenderer) PauseRotation() {
+	r.paused.Store(true)
+}
+
+// ResumeRotation carries on stepping through the pages.
+func (r *ClockRenderer) ResumeRotation() {
+	r.paused.Store(false)
+}
+
+// RotationPaused reports whether the rotation is paused.
+func (r *ClockRenderer) RotationPaused() bool {
+	return r.paused.Load()
+}
+
+// Brightness returns the brightness percentage now, and whether it has been set
+// manually rather than following the curve.
+func (r *ClockRenderer) Brightness() (level int, overridden bool) {
+	_, overridden = r.brightness.Override()
+	return r.brightness.Level(time.Now()), overridden
+}
+
+// SetBrightness holds the brightness at a percentage until ClearBrightness is called.
+func (r *ClockRenderer) SetBrightness(level int) error {
+	return r.brightness.SetOverride(level)
+}
+
+// ClearBrightness returns the brightness to following the curve.
+func (r *ClockRenderer) ClearBrightness() {
+	r.brightness.ClearOverride()
+}
+
+// Theme returns the name of the theme in use now, and whether it has been chosen
+// at runtime rather than following THEME and NIGHT_THEME.
+func (r *ClockRenderer) Theme() (name string, overridden bool) {
+	return r.currentTheme(time.Now()).Name, r.themes.override.Load() != nil
+}
+
+// Themes lists the names of the themes which can be switched to.
+func (r *ClockRenderer) Themes() []string {
+	return r.themes.set.Names()
+}
+
+// SetTheme switches to a theme, by name, until ClearTheme is called.
+func (r *ClockRenderer) SetTheme(name string) error {
+	t, ok := r.themes.set.Get(name)
+	if !ok {
+		return fmt.Errorf("no theme %q", name)
+	}
+	r.themes.override.Store(t)
+	return nil
+}
+
+// ClearTheme returns to THEME, or NIGHT_THEME during the night window.
+func (r *ClockRenderer) ClearTheme() {
+	r.themes.override.Store(nil)
+}
+
+// Agents lists the names of the data sources which are running, for use with AgentData.
+func (r *ClockRenderer) Agents() []string {
+	names := []string{"weather", "diagnostics"}
+	if r.airQuality != nil {
+		names = append(names, "airquality")
+	}
+	if r.sensors != nil {
+		names = append(names, "sensors")
+	}
+	if r.mediaPlayer != nil {
+		names = append(names, "mediaplayer")
+	}
+	return names
+}
+
+// AgentHealth reports, for each running data source, whether it has data to show.
+func (r *ClockRenderer) AgentHealth() map[string]bool {
+	health := map[string]bool{
+		"weather":     !r.weather.GetToday().SunriseTime.IsZero(),
+		"diagnostics": !r.diagnostics.GetStatus().IsStale(time.Now()),
+	}
+	if r.airQuality != nil {
+		health["airquality"] = r.airQuality.Get().AQI.Value != ""
+	}
+	if r.sensors != nil {
+		health["sensors"] = len(r.sensors.GetAllSensors()) > 0
+	}
+	if r.mediaPlayer != nil {
+		health["mediaplayer"] = len(r.mediaPlayer.GetAllPlayers()) > 0
+	}
+	return health
+}
+
+// AgentData returns a copy of the data an agent has cached, suitable for encoding
+// as JSON. It returns false if there is no such agent, or it is not configured.
+func (r *ClockRenderer) AgentData(name string) (any, bool) {
+	switch name {
+	case "weather":
+		return struct {
+			Today    any `json:"today"`
+			Tomorrow any `json:"tomorrow"`
+		}{r.weather.GetToday(), r.weather.GetTomorrow()}, true
+
+	case "diagnostics":
+		return r.diagnostics.GetStatus(), true
+
+	case "airquality":
+		if r.airQuality == nil {
+			return nil, false
+		}
+		return r.airQuality.Get(), true
+
+	case "sensors":
+		if r.sensors == nil {
+			return nil, false
+		}
+		areas := []hasensors.AreaSensors{}
+		for _, name := range r.sensors.GetAreas() {
+			if area, ok := r.sensors.GetArea(name); ok {
+				areas = append(areas, area)
+			}
+		}
+		return areas, true
+
+	case "mediaplayer":
+		if r.mediaPlayer == nil {
+			return nil, false
+		}
+		return r.mediaPlayer.GetAllPlayers(), true
+	}
+
+	return nil, false
+}
@@ -0,0 +1,131 @@
+package clock
+
+import (
+	"embed"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/g-wilson/led/internal/bitmapfont"
+)
+
+// Names of the bundled fonts.
+const (
+	// FontDefault is tom-thumb, a tiny 3x5 font used for most text.
+	FontDefault = "tom-thumb"
+	// FontBody is a classic 5x7 font, for text which has the room.
+	FontBody = "5x7"
+	// FontLargeDigits is a 16 pixel high seven-segment style font with digits, ':',
+	// '-' and '.' only, for showing the time.
+	FontLargeDigits = "digits-large"
+	// FontFixed is the X11 misc-fixed 7x13 font, covering Latin, Greek and Cyrillic.
+	FontFixed = "7x13"
+	// FontAccents has tom-thumb's letters with accents, as a fallback for tom-thumb.
+	FontAccents = "tom-thumb-accents"
+)
+
+//go:embed fonts/*.json fonts/*.bdf
+var fontFiles embed.FS
+
+var bundledFonts = []struct {
+	name string
+	file string
+	opts bitmapfont.FopixOptions
+}{
+	{FontDefault, "fonts/tom-thumb-new.json", bitmapfont.FopixOptions{Baseline: 6}},
+	{FontBody, "fonts/5x7.json", bitmapfont.FopixOptions{Baseline: 7}},
+	{FontLargeDigits, "fonts/digits-large.json", bitmapfont.FopixOptions{Proportional: true, Spacing: 2, TabularDigits: true}},
+	{name: FontFixed, file: "fonts/7x13.bdf"},
+	{name: FontAccents, file: "fonts/tom-thumb-accents.bdf"},
+}
+
+// loadFonts loads the bundled fonts, then any BDF fonts from the configured files
+// and directory. Configured fonts are named after their file, without the extension.
+// Bundled fonts keep their names, so a font directory with its own 5x7.bdf doesn't
+// change the fonts the pages are laid out with, and configured fonts sharing a name
+// with one are skipped. Each font is chained to the named fallback fonts, which it
+// takes glyphs it lacks from if they fit.
+func loadFonts(files []string, dir string, fallbacks []string) (map[string]*bitmapfont.Chain, error) {
+	fonts := make(map[string]*bitmapfont.Font, len(bundledFonts))
+	bundled := make(map[string]bool, len(bundledFonts))
+	for _, f := range bundledFonts {
+		bundled[f.name] = true
+		data, err := fontFiles.ReadFile(f.file)
+		if err != nil {
+			return nil, fmt.Errorf("error reading font %s: %w", f.name, err)
+		}
+		var font *bitmapfont.Font
+		if path.Ext(f.file) == ".bdf" {
+			font, err = bitmapfont.LoadBDF(f.name, data)
+		} else {
+			font, err = bitmapfont.LoadFopix(data, f.opts)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error loading font %s: %w", f.name, err)
+		}
+		fonts[f.name] = font
+	}
+
+	if dir != "" {
+		matches, err := filepath.Glob(filepath.Join(dir, "*.bdf"))
+		if err != nil {
+			return nil, fmt.Errorf("error listing fonts in %s: %w", dir, err)
+		}
+		files = append(matches, files...)
+	}
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("error reading font: %w", err)
+		}
+		name := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+		if bundled[name] {
+			log.Printf("skipping font %s from %s: a bundled font has the same name", name, file)
+			continue
+		}
+		font, err := bitmapfont.LoadBDF(name, data)
+		if err != nil {
+			return nil, err
+		}
+		fonts[name] = font
+	}
+
+	var chain []*bitmapfont.Font
+	for _, name := range fallbacks {
+		font, ok := fonts[strings.TrimSpace(name)]
+		if !ok {
+			return nil, fmt.Errorf("unknown fallback font %q", name)
+		}
+		chain = append(chain, font)
+	}
+
+	chains := make(map[string]*bitmapfont.Chain, len(fonts))
+	for name, font := range fonts {
+		chains[name] = bitmapfont.NewChain(font, chain...)
+	}
+
+	return chains, nil
+}
+
+// Font returns a font by name, or the default font if there is no such font.
+func (r *ClockRenderer) Font(name string) bitmapfont.Face {
+	if f, ok := r.fonts[name]; ok {
+		return f
+	}
+	return r.font
+}
+
+// Fonts returns the names of the available fonts, sorted.
+func (r *ClockRenderer) Fonts() []string {
+	names := make([]string, 0, len(r.fonts))
+	for name := range r.fonts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
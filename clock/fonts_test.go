@@ -0,0 +1,88 @@
+package clock
+
+import (
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeBDF writes a BDF font with a single '0' glyph of the given cell size, as
+// fonts such as 6x10.bdf have.
+func writeBDF(t *testing.T, dir, name string, width, height int) string {
+	t.Helper()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "STARTFONT 2.1\nFONTBOUNDINGBOX %d %d 0 -2\nFONT_ASCENT %d\nFONT_DESCENT 2\n", width, height, height-2)
+	fmt.Fprintf(&b, "STARTCHAR zero\nENCODING 48\nDWIDTH %d 0\nBBX %d %d 0 -2\nBITMAP\n", width, width, height)
+	for range height {
+		b.WriteString("F0\n")
+	}
+	b.WriteString("ENDCHAR\nENDFONT\n")
+
+	file := filepath.Join(dir, name+".bdf")
+	if err := os.WriteFile(file, []byte(b.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestLoadFontsKeepsBundledNames(t *testing.T) {
+	dir := t.TempDir()
+	writeBDF(t, dir, FontBody, 9, 18)
+	writeBDF(t, dir, "6x10", 6, 10)
+
+	fonts, err := loadFonts(nil, dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := fonts[FontBody].Measure("0"); got != 6 {
+		t.Errorf("5x7 from the font directory replaced the bundled font, '0' is %d wide", got)
+	}
+	if _, ok := fonts["6x10"]; !ok {
+		t.Error("6x10 from the font directory not loaded")
+	}
+}
+
+func TestBodyFontScalesLayout(t *testing.T) {
+	dir := t.TempDir()
+	fonts, err := loadFonts([]string{
+		writeBDF(t, dir, "4x6", 4, 6),
+		writeBDF(t, dir, "6x10", 6, 10),
+		writeBDF(t, dir, "6x13", 6, 13),
+		writeBDF(t, dir, "9x18", 9, 18),
+	}, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		font string
+		size image.Point
+		used bool
+		// scale is 8 on the reference layout, scaled
+		scale int
+	}{
+		// no bigger than tom-thumb, so used on every panel
+		{font: "4x6", size: image.Pt(64, 32), used: true, scale: 8},
+		{font: "6x10", size: image.Pt(64, 32), scale: 8},
+		{font: "6x10", size: image.Pt(96, 48), used: true, scale: 12},
+		// taller than it is wide, so scaled by height
+		{font: "6x13", size: image.Pt(96, 48), scale: 8},
+		{font: "6x13", size: image.Pt(104, 52), used: true, scale: 13},
+		{font: "9x18", size: image.Pt(128, 64), scale: 8},
+		{font: "9x18", size: image.Pt(144, 72), used: true, scale: 18},
+	} {
+		r := &ClockRenderer{fonts: fonts, font: fonts[FontDefault], bodyFont: fonts[tc.font]}
+		l := r.Layout(image.NewRGBA(image.Rectangle{Max: tc.size}))
+
+		if used := l.Font == r.bodyFont; used != tc.used {
+			t.Errorf("%s on %v: used %v, want %v", tc.font, tc.size, used, tc.used)
+		}
+		if got := l.Scale(8); got != tc.scale {
+			t.Errorf("%s on %v: Scale(8) = %d, want %d", tc.font, tc.size, got, tc.scale)
+		}
+	}
+}
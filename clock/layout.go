@@ -0,0 +1,116 @@
+package clock
+
+import (
+	"image"
+
+	"github.com/g-wilson/led/internal/bitmapfont"
+)
+
+// Pages are designed for a 64x32 panel, the reference layout, and adapt it to the
+// canvas they are given:
+//   - panels big enough for the body font, 5x7 unless BODY_FONT picks another, use
+//     it with the layout scaled up to match: 5x7 is half as wide again as tom-thumb,
+//     so it is used on panels half as big again each way
+//   - taller panels centre the content in the space below the header
+//   - wider panels keep the content to the left, with full width lines running
+//     on across the panel
+//
+// Pages with lists, charts or pictures use the extra room where they can, with
+// more rows, side by side columns, or bigger pictures.
+const (
+	referenceWidth  = 64
+	referenceHeight = 32
+
+	// headerHeight is the rows taken by the time along the top of pages which are
+	// not full screen, on the reference layout.
+	headerHeight = 5
+)
+
+// Layout maps positions on the reference layout to the canvas being drawn on.
+type Layout struct {
+	// Bounds is the whole canvas.
+	Bounds image.Rectangle
+	// Font is the text font for the size of the canvas.
+	Font bitmapfont.Face
+
+	// the layout is scaled by num/den, the ratio of the font's size to tom-thumb's,
+	// by width or height, whichever is greater
+	num, den int
+	// offset moves content down to centre it on a taller canvas
+	offset int
+}
+
+// Layout returns the layout for the canvas, for pages to place their content with.
+func (r *ClockRenderer) Layout(c *image.RGBA) Layout {
+	b := c.Bounds()
+	l := Layout{Bounds: b, Font: r.font, num: 1, den: 1}
+
+	if body := r.bodyFont; body != nil {
+		num, den := body.Measure("0"), r.font.Measure("0")
+		if body.Height()*den > num*r.font.Height() {
+			num, den = body.Height(), r.font.Height()
+		}
+		if b.Dx()*den >= referenceWidth*num && b.Dy()*den >= referenceHeight*num {
+			l.Font, l.num, l.den = body, num, den
+		}
+	}
+	l.offset = max(0, (b.Dy()-l.Scale(referenceHeight))/2)
+
+	return l
+}
+
+// Scale returns a distance on the reference layout scaled to the canvas.
+func (l Layout) Scale(n int) int {
+	return n * l.num / l.den
+}
+
+// X returns the column on the canvas for a column on the reference layout.
+func (l Layout) X(x int) int {
+	return l.Bounds.Min.X + l.Scale(x)
+}
+
+// Y returns the row on the canvas for a row on the reference layout.
+func (l Layout) Y(y int) int {
+	return l.Bounds.Min.Y + l.offset + l.Scale(y)
+}
+
+// Pt returns the point on the canvas for a point on the reference layout.
+func (l Layout) Pt(x, y int) image.Point {
+	return image.Pt(l.X(x), l.Y(y))
+}
+
+// Rect returns the rectangle on the canvas for a rectangle on the reference layout.
+// A rectangle reaching the right edge of the reference layout reaches the right
+// edge of the canvas.
+func (l Layout) Rect(x0, y0, x1, y1 int) image.Rectangle {
+	r := image.Rect(l.X(x0), l.Y(y0), l.X(x1), l.Y(y1))
+	if x1 >= referenceWidth {
+		r.Max.X = l.Bounds.Max.X
+	}
+	return r
+}
+
+// Line returns a rectangle the width of the canvas, one line of text high, with its
+// top at row y of the reference layout.
+func (l Layout) Line(y int) image.Rectangle {
+	top := l.Y(y)
+	return image.Rect(l.Bounds.Min.X, top, l.Bounds.Max.X, top+l.Font.Height())
+}
+
+// Body is the canvas below the header, for pages which fill whatever space they have.
+func (l Layout) Body() image.Rectangle {
+	b := l.Bounds
+	b.Min.Y += l.Scale(headerHeight)
+	return b
+}
+
+// Columns splits the rows of r into as many side by side columns as fit at the
+// width of the reference layout, for pages which can spread their content out.
+func (l Layout) Columns(r image.Rectangle) []image.Rectangle {
+	n := max(1, r.Dx()/l.Scale(referenceWidth))
+	cols := make([]image.Rectangle, n)
+	for i := range cols {
+		cols[i] = image.Rect(r.Min.X+i*r.Dx()/n, r.Min.Y, r.Min.X+(i+1)*r.Dx()/n, r.Max.Y)
+	}
+	return cols
+}
@@ -0,0 +1,433 @@
+package clock
+
+import (
+	"context"
+	"image"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/g-wilson/led/config"
+	"github.com/g-wilson/led/internal/calendar"
+	"github.com/g-wilson/led/internal/diagnostics"
+	"github.com/g-wilson/led/internal/weather"
+
+	"gopkg.in/yaml.v3"
+)
+
+// referenceSizes are the panel sizes the layouts are checked on.
+var referenceSizes = []image.Point{{64, 32}, {64, 64}, {128, 32}}
+
+// pageOptions are the playlist options for pages which need some to be drawn.
+var pageOptions = map[string]string{
+	"graph": "{source: ping}",
+}
+
+// canvasMargin surrounds the canvas pages draw on in tests, so that anything drawn
+// outside the page's bounds shows up.
+const canvasMargin = 8
+
+type fakeWeather struct{}
+
+func (fakeWeather) GetTwoDayWeatherAtLocation(ctx context.Context, lat, lon string) (weather.TwoDayWeather, error) {
+	now := time.Now()
+	day := weather.DayWeather{
+		TemperatureLow:  3,
+		TemperatureHigh: 11,
+		WeatherCode:     1101,
+		Windy:           true,
+		SunriseTime:     now.Add(-6 * time.Hour),
+		SunsetTime:      now.Add(6 * time.Hour),
+		MoonriseTime:    now.Add(-2 * time.Hour),
+		MoonsetTime:     now.Add(8 * time.Hour),
+	}
+	return weather.TwoDayWeather{
+		Today:    day,
+		Tomorrow: day,
+		Hourly:   []weather.HourWeather{{Time: now.Truncate(time.Hour), Temperature: 7}},
+	}, nil
+}
+
+// testRenderer creates a clock with fake data sources, and a calendar event an
+// hour away with a picture.
+func testRenderer(t *testing.T) *ClockRenderer {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	fonts, err := loadFonts(nil, "", []string{"tom-thumb-accents", "7x13"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	icons, err := loadWeatherIcons()
+	if err != nil {
+		t.Fatal(err)
+	}
+	themes, err := loadThemes(&config.Settings{Theme: "default"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events := filepath.Join(t.TempDir(), "events.yaml")
+	soon := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	data := "events:\n  - name: Test Event\n    time: \"" + soon + "\"\n    image: builtin:xmastree\n"
+	if err := os.WriteFile(events, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := calendar.Load([]string{events}); err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := weather.New(ctx, fakeWeather{}, weather.AgentOptions{Refresh: 3600})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a cancelled context stops the agent pinging anything
+	stopped, stop := context.WithCancel(ctx)
+	stop()
+	diag, err := diagnostics.New(stopped)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range 50 {
+		diag.GetPingHistory().Add(time.Now().Add(time.Duration(i-50)*time.Minute), float64(20+i*7%90))
+	}
+
+	return &ClockRenderer{
+		fonts:        fonts,
+		font:         fonts[FontDefault],
+		bodyFont:     fonts[FontBody],
+		weatherIcons: icons,
+		weather:      w,
+		diagnostics:  diag,
+		location:     time.UTC,
+		themes:       themes,
+		frameTheme:   themes.day,
+		activePage:   -1,
+	}
+}
+
+// drawPage draws a frame of the page on a canvas of the given size, inside a larger
+// image so the test can see anything drawn outside it.
+func drawPage(t *testing.T, r *ClockRenderer, pg Page, size image.Point) (*image.RGBA, *image.RGBA) {
+	t.Helper()
+
+	outer := image.NewRGBA(image.Rect(0, 0, size.X+2*canvasMargin, size.Y+2*canvasMargin))
+	c := outer.SubImage(image.Rect(canvasMargin, canvasMargin, canvasMargin+size.X, canvasMargin+size.Y)).(*image.RGBA)
+
+	pg.Activate()
+	r.pages = []rotationPage{{Page: pg}}
+	r.activePage = 0
+	if err := r.DrawFrame(c); err != nil {
+		t.Fatal(err)
+	}
+	return c, outer
+}
+
+// newPages creates the pages for a playlist entry, with the options in pageOptions.
+func newPages(t *testing.T, r *ClockRenderer, id string) []Page {
+	t.Helper()
+	return newPagesWith(t, r, id, pageOptions[id])
+}
+
+// newPagesWith creates the pages for a playlist entry, with options given as YAML.
+func newPagesWith(t *testing.T, r *ClockRenderer, id, opts string) []Page {
+	t.Helper()
+
+	f, ok := lookupPage(id)
+	if !ok {
+		t.Fatalf("no page %q", id)
+	}
+	entry := PlaylistEntry{ID: id}
+	if opts != "" {
+		var doc yaml.Node
+		if err := yaml.Unmarshal([]byte(opts), &doc); err != nil {
+			t.Fatal(err)
+		}
+		entry.Options = *doc.Content[0]
+	}
+	pages, err := f(r, entry)
+	if err != nil {
+		t.Fatalf("%s: %v", id, err)
+	}
+	return pages
+}
+
+// newPage creates the first page of a playlist entry.
+func newPage(t *testing.T, r *ClockRenderer, id string) Page {
+	t.Helper()
+
+	pages := newPages(t, r, id)
+	if len(pages) == 0 {
+		t.Fatalf("page %q created no pages", id)
+	}
+	return pages[0]
+}
+
+// lit reports whether the pixel at x, y relative to the canvas's origin is lit.
+func lit(c *image.RGBA, x, y int) bool {
+	p := c.RGBAAt(c.Rect.Min.X+x, c.Rect.Min.Y+y)
+	return p.R != 0 || p.G != 0 || p.B != 0
+}
+
+// litBounds returns the smallest rectangle holding every lit pixel in r, relative
+// to the canvas's origin.
+func litBounds(c *image.RGBA, r image.Rectangle) image.Rectangle {
+	var b image.Rectangle
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			if lit(c, x, y) {
+				b = b.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+	return b
+}
+
+func TestLayout(t *testing.T) {
+	r := testRenderer(t)
+
+	for _, tc := range []struct {
+		size   image.Point
+		body   bool // uses the 5x7 font
+		scale  int  // 10 on the reference layout, scaled
+		offset int
+	}{
+		{size: image.Pt(64, 32), scale: 10},
+		{size: image.Pt(64, 64), scale: 10, offset: 16},
+		{size: image.Pt(128, 32), scale: 10},
+		{size: image.Pt(95, 48), scale: 10, offset: 8},
+		{size: image.Pt(96, 47), scale: 10, offset: 7},
+		{size: image.Pt(96, 48), body: true, scale: 15},
+		{size: image.Pt(128, 64), body: true, scale: 15, offset: 8},
+	} {
+		l := r.Layout(image.NewRGBA(image.Rectangle{Max: tc.size}))
+
+		want := r.fonts[FontDefault]
+		if tc.body {
+			want = r.fonts[FontBody]
+		}
+		if l.Font != want {
+			t.Errorf("%v: got font with height %d, want %d", tc.size, l.Font.Height(), want.Height())
+		}
+		if got := l.Scale(10); got != tc.scale {
+			t.Errorf("%v: Scale(10) = %d, want %d", tc.size, got, tc.scale)
+		}
+		if got := l.Y(0); got != tc.offset {
+			t.Errorf("%v: Y(0) = %d, want offset %d", tc.size, got, tc.offset)
+		}
+		if got := l.Rect(0, 0, referenceWidth, 5).Max.X; got != tc.size.X {
+			t.Errorf("%v: full width Rect reaches %d, want %d", tc.size, got, tc.size.X)
+		}
+	}
+}
+
+func TestLayoutFollowsCanvasOrigin(t *testing.T) {
+	r := testRenderer(t)
+
+	l := r.Layout(image.NewRGBA(image.Rect(10, 20, 74, 84)))
+	if got, want := l.Pt(3, 4), image.Pt(13, 40); got != want {
+		t.Errorf("Pt(3, 4) = %v, want %v", got, want)
+	}
+	if got, want := l.Body().Min, image.Pt(10, 25); got != want {
+		t.Errorf("Body starts at %v, want %v", got, want)
+	}
+}
+
+func TestPagesStayInBounds(t *testing.T) {
+	r := testRenderer(t)
+
+	for _, id := range RegisteredPages() {
+		for _, pg := range newPages(t, r, id) {
+			if err := pg.Init(context.Background()); err != nil || !pg.Ready() {
+				continue
+			}
+			for _, size := range append(referenceSizes, image.Pt(96, 48), image.Pt(128, 64)) {
+				c, outer := drawPage(t, r, pg, size)
+				if litBounds(c, image.Rectangle{Max: size}).Empty() {
+					t.Errorf("%s %v: nothing drawn", id, size)
+				}
+				for y := range outer.Rect.Dy() {
+					for x := range outer.Rect.Dx() {
+						if p := outer.RGBAAt(x, y); !image.Pt(x, y).In(c.Rect) && (p.R != 0 || p.G != 0 || p.B != 0) {
+							t.Errorf("%s %v: drew at %d,%d outside the canvas", id, size, x-canvasMargin, y-canvasMargin)
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestHeaderAndTitleRows(t *testing.T) {
+	r := testRenderer(t)
+	pg := newPage(t, r, "today")
+
+	for i, titleTop := range []int{8, 24, 8} {
+		size := referenceSizes[i]
+		c, _ := drawPage(t, r, pg, size)
+		height := r.font.Height()
+
+		// the time stays along the top, however tall the panel
+		header := litBounds(c, image.Rect(0, 0, size.X, 5))
+		if header.Empty() || header.Min.X != 0 {
+			t.Errorf("%v: time not at top left, lit %v", size, header)
+		}
+
+		title := litBounds(c, image.Rect(0, titleTop, r.font.Measure("Today"), titleTop+height))
+		if title.Empty() {
+			t.Errorf("%v: title not on row %d", size, titleTop)
+		}
+		if above := litBounds(c, image.Rect(0, 5, size.X, titleTop)); !above.Empty() {
+			t.Errorf("%v: drew %v between the header and the title on row %d", size, above, titleTop)
+		}
+	}
+}
+
+func TestBigClockRows(t *testing.T) {
+	r := testRenderer(t)
+	pg := newPage(t, r, "bigclock")
+
+	for i, digitsTop := range []int{3, 19, 3} {
+		size := referenceSizes[i]
+		c, _ := drawPage(t, r, pg, size)
+
+		digitsBottom := digitsTop + r.Font(FontLargeDigits).Height()
+		dateTop := digitsBottom + 2
+		dateBottom := dateTop + r.font.Height()
+
+		if litBounds(c, image.Rect(0, digitsTop, size.X, digitsBottom)).Empty() {
+			t.Errorf("%v: no time on rows %d-%d", size, digitsTop, digitsBottom)
+		}
+		if litBounds(c, image.Rect(0, dateTop, size.X, dateBottom)).Empty() {
+			t.Errorf("%v: no date on rows %d-%d", size, dateTop, dateBottom)
+		}
+		if above := litBounds(c, image.Rect(0, 0, size.X, digitsTop)); !above.Empty() {
+			t.Errorf("%v: drew %v above the time", size, above)
+		}
+		if below := litBounds(c, image.Rect(0, dateBottom, size.X, size.Y)); !below.Empty() {
+			t.Errorf("%v: drew %v below the date", size, below)
+		}
+	}
+}
+
+func TestCountdownImagePosition(t *testing.T) {
+	r := testRenderer(t)
+	pg := newPage(t, r, "countdown")
+
+	event := calendar.GetNextEvent()
+	if event == nil || event.Image == nil {
+		t.Fatal("no event with an image")
+	}
+	frame := event.Image.Frame(0)
+
+	for i, pos := range []image.Point{{44, 9}, {44, 25}, {108, 9}} {
+		size := referenceSizes[i]
+		c, _ := drawPage(t, r, pg, size)
+
+		// the event's name runs across the bottom of the image
+		b := frame.Bounds()
+		b.Max.Y = b.Min.Y + 15 - 9
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				want := frame.At(x, y)
+				if _, _, _, a := want.RGBA(); a != 0xffff {
+					continue
+				}
+				got := c.At(c.Rect.Min.X+pos.X+x-b.Min.X, c.Rect.Min.Y+pos.Y+y-b.Min.Y)
+				if !sameColour(got, want) {
+					t.Fatalf("%v: image not at %v, pixel %d,%d differs", size, pos, x, y)
+				}
+			}
+		}
+	}
+}
+
+func sameColour(a, b interface{ RGBA() (r, g, b, a uint32) }) bool {
+	ar, ag, ab, aa := a.RGBA()
+	br, bg, bb, ba := b.RGBA()
+	return ar == br && ag == bg && ab == bb && aa == ba
+}
+
+func TestMoonCentre(t *testing.T) {
+	r := testRenderer(t)
+	pg := newPage(t, r, "moon")
+
+	for i, centre := range []image.Point{{32, 16}, {32, 32}, {64, 16}} {
+		size := referenceSizes[i]
+		c, _ := drawPage(t, r, pg, size)
+
+		// the disc, between the header and the phase name along the bottom
+		disc := litBounds(c, image.Rect(0, 5, size.X, size.Y-6))
+		got := image.Pt((disc.Min.X+disc.Max.X)/2, (disc.Min.Y+disc.Max.Y)/2)
+		if d := got.Sub(centre); d.X < -1 || d.X > 1 || d.Y < -1 || d.Y > 1 {
+			t.Errorf("%v: moon centred at %v, want %v", size, got, centre)
+		}
+	}
+}
+
+func TestNotificationRows(t *testing.T) {
+	r := testRenderer(t)
+	long := strings.Repeat("A long notification which has to scroll ", 4)
+
+	for _, tc := range []struct {
+		size image.Point
+		body string
+		// rows the banner and the scrolling text take up, one line of the font
+		rows int
+	}{
+		{size: image.Pt(64, 32), body: FontBody, rows: 8},
+		{size: image.Pt(128, 64), body: FontBody, rows: 8},
+		{size: image.Pt(128, 64), body: "7x13", rows: 13},
+	} {
+		r.bodyFont = r.fonts[tc.body]
+		n := activeNotification{Notification: Notification{Text: long, Priority: PriorityNormal}, text: &Marquee{}}
+
+		c := image.NewRGBA(image.Rectangle{Max: tc.size})
+		r.drawBanner(c, n)
+		band := litBounds(c, c.Rect)
+		if want := image.Rect(0, tc.size.Y-tc.rows, tc.size.X, tc.size.Y); band != want {
+			t.Errorf("%s on %v: banner covers %v, want %v", tc.body, tc.size, band, want)
+		}
+
+		c = image.NewRGBA(image.Rectangle{Max: tc.size})
+		r.drawNotification(c, n, time.Now())
+		text := litBounds(c, c.Rect)
+		if top := (tc.size.Y - tc.rows) / 2; text.Min.Y < top || text.Max.Y > top+tc.rows || text.Dy() < tc.rows-3 {
+			t.Errorf("%s on %v: scrolling text covers rows %d to %d, want within %d to %d", tc.body, tc.size, text.Min.Y, text.Max.Y, top, top+tc.rows)
+		}
+	}
+}
+
+func TestGaugeRows(t *testing.T) {
+	r := testRenderer(t)
+	pg := newPagesWith(t, r, "graph", "{source: ping, style: gauge, min: 0, max: 200}")[0]
+
+	for _, tc := range []struct {
+		size image.Point
+		bar  image.Rectangle
+	}{
+		{size: image.Pt(64, 32), bar: image.Rect(1, 16, 63, 22)},
+		{size: image.Pt(64, 64), bar: image.Rect(1, 32, 63, 38)},
+		{size: image.Pt(128, 32), bar: image.Rect(1, 16, 127, 22)},
+		// the body font, at half as big again
+		{size: image.Pt(96, 48), bar: image.Rect(1, 24, 95, 33)},
+	} {
+		c, _ := drawPage(t, r, pg, tc.size)
+		l := r.Layout(c)
+
+		// between the title and the labels, only the bar is drawn
+		titleBottom := l.Scale(headerHeight) + l.Font.Height()
+		labels := l.Line(24).Inset(1).Sub(c.Rect.Min)
+		if bar := litBounds(c, image.Rect(0, titleBottom, tc.size.X, labels.Min.Y)); bar != tc.bar {
+			t.Errorf("%v: gauge bar covers %v, want %v", tc.size, bar, tc.bar)
+		}
+		if litBounds(c, labels).Empty() {
+			t.Errorf("%v: no labels on rows %d-%d", tc.size, labels.Min.Y, labels.Max.Y)
+		}
+	}
+}
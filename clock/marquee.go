@@ -0,0 +1,113 @@
+package clock
+
+import (
+	"image"
+	"image/color"
+	"time"
+
+	"github.com/g-wilson/led/internal/bitmapfont"
+	"github.com/g-wilson/led/internal/framestreamer"
+)
+
+const (
+	defaultMarqueeSpeed = 15.0 // pixels per second
+	defaultMarqueePause = 1500 * time.Millisecond
+
+	// marqueeRefresh is the frame rate a page needs while one of its marquees is
+	// scrolling. At the default speed, text moves one pixel per frame.
+	marqueeRefresh = framestreamer.FifteenFPS * time.Millisecond
+)
+
+// Marquee draws a line of text clipped to a region, scrolling it back and forth
+// horizontally if it is too wide to fit. Scrolling pauses at each end. Text which
+// fits is drawn still, aligned within the region.
+//
+// A page should keep one Marquee per line of text and Reset them all when it is
+// activated, so scrolling starts from the beginning each time the page is shown.
+type Marquee struct {
+	// Speed is the scrolling speed in pixels per second.
+	Speed float64
+	// Pause is how long the text is held still at each end.
+	Pause time.Duration
+	// Align places text within the region when it fits.
+	Align bitmapfont.Align
+	// Font is the font to draw in, or the canvas's text font if nil.
+	Font bitmapfont.Face
+
+	start     time.Time
+	scrolling bool
+}
+
+// Reset restarts scrolling from the beginning of the text.
+func (m *Marquee) Reset() {
+	m.start = time.Time{}
+}
+
+// Scrolling reports whether the text was too wide for its region when last drawn.
+func (m *Marquee) Scrolling() bool {
+	return m.scrolling
+}
+
+// Draw draws text into the region of the canvas, scrolled to the position for the current time.
+func (m *Marquee) Draw(r *ClockRenderer, c *image.RGBA, region image.Rectangle, text string, col color.RGBA) {
+	now := time.Now()
+	if m.start.IsZero() {
+		m.start = now
+	}
+
+	font := m.Font
+	if font == nil {
+		font = r.Layout(c).Font
+	}
+
+	width := font.Measure(text)
+	overflow := width - region.Dx()
+	m.scrolling = overflow > 0
+
+	pos := region.Min
+	if m.scrolling {
+		pos.X -= m.offset(overflow, now.Sub(m.start))
+	} else {
+		pos.X += bitmapfont.AlignOffset(width, region.Dx(), m.Align)
+	}
+
+	r.drawTextClipped(c, font, pos, text, col, region)
+}
+
+// offset returns how far the text has scrolled after elapsed, travelling to the
+// end of the text and back again with a pause at each end.
+func (m *Marquee) offset(overflow int, elapsed time.Duration) int {
+	speed := m.Speed
+	if speed <= 0 {
+		speed = defaultMarqueeSpeed
+	}
+	pause := m.Pause
+	if pause <= 0 {
+		pause = defaultMarqueePause
+	}
+
+	scroll := time.Duration(float64(overflow) / speed * float64(time.Second))
+	t := elapsed % (2 * (pause + scroll))
+
+	switch {
+	case t < pause:
+		return 0
+	case t < pause+scroll:
+		return int((t - pause).Seconds() * speed)
+	case t < 2*pause+scroll:
+		return overflow
+	default:
+		return overflow - int((t-2*pause-scroll).Seconds()*speed)
+	}
+}
+
+// marqueeRefreshFor returns the refresh rate a page needs for its marquees:
+// fast while any of them are scrolling, otherwise the default.
+func marqueeRefreshFor(marquees ...*Marquee) time.Duration {
+	for _, m := range marquees {
+		if m.Scrolling() {
+			return marqueeRefresh
+		}
+	}
+	return 0
+}
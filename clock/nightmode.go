@@ -0,0 +1,155 @@
+package clock
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"strings"
+	"time"
+
+	"github.com/g-wilson/led/config"
+	"github.com/g-wilson/led/internal/bitmapfont"
+	"github.com/g-wilson/led/internal/schedule"
+)
+
+// NightMode is what the clock shows during the night window.
+type NightMode string
+
+const (
+	// NightModeOff disables the night window entirely.
+	NightModeOff NightMode = "off"
+	// NightModeBlank turns the display off.
+	NightModeBlank NightMode = "blank"
+	// NightModeClock shows only the time.
+	NightModeClock NightMode = "clock"
+	// NightModeDim shows only the time, in dim red.
+	NightModeDim NightMode = "dim"
+	// NightModePages limits the rotation to the pages listed in NIGHT_PAGES.
+	NightModePages NightMode = "pages"
+)
+
+// colourNightDim is the dim red of NightModeDim, whatever the theme.
+var colourNightDim = color.RGBA{60, 0, 0, 255}
+
+// used for sunrise and sunset relative times until the weather has loaded
+const (
+	fallbackSunrise = 6 * time.Hour
+	fallbackSunset  = 20 * time.Hour
+)
+
+// nightSettings is the parsed night mode configuration.
+type nightSettings struct {
+	mode     NightMode
+	schedule schedule.Schedule
+	pages    map[string]bool // page IDs shown in NightModePages
+}
+
+func parseNightSettings(cfg *config.Settings) (nightSettings, error) {
+	n := nightSettings{mode: NightMode(strings.ToLower(cfg.NightMode))}
+
+	switch n.mode {
+	case NightModeOff, NightModeBlank, NightModeClock, NightModeDim, NightModePages:
+	default:
+		return n, fmt.Errorf("invalid NIGHT_MODE %q (expected off, blank, clock, dim or pages)", cfg.NightMode)
+	}
+
+	weekday, err := parseWindow(cfg.NightStart, cfg.NightEnd)
+	if err != nil {
+		return n, fmt.Errorf("invalid night window: %w", err)
+	}
+	n.schedule.Weekday = weekday
+
+	if cfg.NightWeekendStart != "" || cfg.NightWeekendEnd != "" {
+		start, end := cfg.NightWeekendStart, cfg.NightWeekendEnd
+		if start == "" {
+			start = cfg.NightStart
+		}
+		if end == "" {
+			end = cfg.NightEnd
+		}
+		weekend, err := parseWindow(start, end)
+		if err != nil {
+			return n, fmt.Errorf("invalid weekend night window: %w", err)
+		}
+		n.schedule.Weekend = &weekend
+
+		n.schedule.WeekendDays, err = schedule.ParseWeekdays(cfg.NightWeekendDays)
+		if err != nil {
+			return n, fmt.Errorf("invalid NIGHT_WEEKEND_DAYS: %w", err)
+		}
+	}
+
+	if n.mode == NightModePages {
+		if len(cfg.NightPages) == 0 {
+			return n, fmt.Errorf("NIGHT_PAGES must list at least one page ID when NIGHT_MODE is pages")
+		}
+		n.pages = make(map[string]bool, len(cfg.NightPages))
+		for _, id := range cfg.NightPages {
+			if _, ok := lookupPage(id); !ok {
+				return n, fmt.Errorf("invalid NIGHT_PAGES: unknown page id %q", id)
+			}
+			n.pages[id] = true
+		}
+	}
+
+	return n, nil
+}
+
+func parseWindow(start, end string) (schedule.Window, error) {
+	s, err := schedule.ParseTimeSpec(start)
+	if err != nil {
+		return schedule.Window{}, err
+	}
+	e, err := schedule.ParseTimeSpec(end)
+	if err != nil {
+		return schedule.Window{}, err
+	}
+	return schedule.Window{Start: s, End: e}, nil
+}
+
+// SunTimes resolves sunrise and sunset for the given day from today's forecast,
+// falling back to fixed times if the forecast has not loaded.
+func (r *ClockRenderer) SunTimes(day time.Time) (sunrise, sunset time.Time) {
+	year, month, date := day.Date()
+	midnight := time.Date(year, month, date, 0, 0, 0, 0, day.Location())
+
+	w := r.weather.GetToday()
+	if w.SunriseTime.IsZero() || w.SunsetTime.IsZero() {
+		return midnight.Add(fallbackSunrise), midnight.Add(fallbackSunset)
+	}
+
+	// the time of day barely moves from one day to the next, so today's times
+	// are close enough for yesterday and tomorrow
+	return onDate(w.SunriseTime.In(day.Location()), midnight), onDate(w.SunsetTime.In(day.Location()), midnight)
+}
+
+func onDate(t time.Time, day time.Time) time.Time {
+	year, month, date := day.Date()
+	return time.Date(year, month, date, t.Hour(), t.Minute(), t.Second(), 0, day.Location())
+}
+
+// nightMode returns the night mode in effect at the given time, or NightModeOff
+// outside the night window.
+func (r *ClockRenderer) nightMode(now time.Time) NightMode {
+	if r.night.mode == NightModeOff || !r.inNightWindow(now) {
+		return NightModeOff
+	}
+	return r.night.mode
+}
+
+// inNightWindow reports whether the time falls in the night window, whatever the
+// night mode. The window is ignored in debug mode.
+func (r *ClockRenderer) inNightWindow(now time.Time) bool {
+	return !r.debug && r.night.schedule.Contains(now.In(r.location), r)
+}
+
+// allowedAtNight reports whether the page may be shown in the reduced night rotation.
+func (r *ClockRenderer) allowedAtNight(p rotationPage) bool {
+	return r.night.pages[p.id]
+}
+
+// drawNightClock draws the time on its own, centred on the canvas.
+func (r *ClockRenderer) drawNightClock(c *image.RGBA, col color.RGBA) {
+	text := time.Now().In(r.location).Format("15:04")
+	r.DrawTextIn(c, c.Bounds(), text, col, bitmapfont.Layout{Align: bitmapfont.AlignCentre, VAlign: bitmapfont.VAlignMiddle})
+}
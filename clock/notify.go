@@ -0,0 +1,406 @@
+package clock
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/g-wilson/led/internal/bitmapfont"
+	"github.com/g-wilson/led/internal/theme"
+
+	"golang.org/x/image/draw"
+)
+
+// NotificationStyle is how a notification is drawn.
+type NotificationStyle string
+
+const (
+	// NotificationBanner draws the text across the bottom of the current page.
+	NotificationBanner NotificationStyle = "banner"
+	// NotificationFullScreen replaces the page with the text.
+	NotificationFullScreen NotificationStyle = "fullscreen"
+	// NotificationFlash replaces the page with the text, flashing the background.
+	NotificationFlash NotificationStyle = "flash"
+)
+
+// Priority orders queued notifications. Notifications at PriorityHigh and above
+// interrupt the current page straight away; lower priorities wait for the rotation
+// to move on to the next page.
+type Priority int
+
+const (
+	PriorityLow Priority = iota
+	PriorityNormal
+	PriorityHigh
+	// PriorityUrgent notifications are also shown during the night window.
+	PriorityUrgent
+)
+
+var priorityNames = map[Priority]string{
+	PriorityLow:    "low",
+	PriorityNormal: "normal",
+	PriorityHigh:   "high",
+	PriorityUrgent: "urgent",
+}
+
+func (p Priority) String() string {
+	if name, ok := priorityNames[p]; ok {
+		return name
+	}
+	return fmt.Sprintf("Priority(%d)", int(p))
+}
+
+// ParsePriority parses a priority name: low, normal, high or urgent.
+func ParsePriority(s string) (Priority, error) {
+	for p, name := range priorityNames {
+		if strings.EqualFold(s, name) {
+			return p, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown priority %q (expected low, normal, high or urgent)", s)
+}
+
+func (p Priority) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+func (p *Priority) UnmarshalText(b []byte) (err error) {
+	*p, err = ParsePriority(string(b))
+	return err
+}
+
+const (
+	defaultNotificationTTL      = 5 * time.Minute
+	defaultNotificationDuration = 5 * time.Second
+
+	flashInterval = 500 * time.Millisecond
+)
+
+// Notification is a message to put on screen over the page rotation.
+type Notification struct {
+	// Key identifies the notification. Posting a notification with the same key as
+	// one already queued replaces it rather than queueing another.
+	Key      string
+	Text     string
+	Priority Priority
+	Style    NotificationStyle
+	// TTL is how long the notification stays queued before it is dropped, whether or
+	// not it has been shown. Defaults to five minutes.
+	TTL time.Duration
+	// Duration is how long each showing stays on screen. Defaults to five seconds.
+	Duration time.Duration
+	// Repeat is how many more times the notification is shown after the first, each
+	// after the rotation has moved on a page.
+	Repeat int
+	// Colour overrides the text colour, which otherwise depends on the priority.
+	Colour *color.RGBA
+}
+
+func (n Notification) validate() error {
+	if n.Key == "" {
+		return fmt.Errorf("notification key is required")
+	}
+	switch n.Style {
+	case NotificationBanner, NotificationFullScreen, NotificationFlash:
+	default:
+		return fmt.Errorf("unknown notification style %q (expected banner, fullscreen or flash)", n.Style)
+	}
+	if n.Priority < PriorityLow || n.Priority > PriorityUrgent {
+		return fmt.Errorf("unknown notification priority %d", n.Priority)
+	}
+	if n.TTL < 0 || n.Duration < 0 || n.Repeat < 0 {
+		return fmt.Errorf("notification TTL, duration and repeat cannot be negative")
+	}
+	return nil
+}
+
+func (n Notification) colour(t *theme.Theme) color.RGBA {
+	switch {
+	case n.Colour != nil:
+		return *n.Colour
+	case n.Priority >= PriorityUrgent:
+		return t.NotificationUrgent
+	case n.Priority >= PriorityHigh:
+		return t.NotificationHigh
+	default:
+		return t.Notification
+	}
+}
+
+// notificationMessage is the JSON form of a notification, as posted to the HTTP API
+// or over MQTT. Durations are Go duration strings ("30s") and the colour is a hex
+// string ("#ff0000").
+type notificationMessage struct {
+	Key      string            `json:"key"`
+	Text     string            `json:"text"`
+	Priority *Priority         `json:"priority"`
+	Style    NotificationStyle `json:"style"`
+	TTL      string            `json:"ttl"`
+	Duration string            `json:"duration"`
+	Repeat   int               `json:"repeat"`
+	Colour   string            `json:"colour"`
+}
+
+// DecodeNotification decodes a notification from a JSON message. Anything which is
+// not a JSON object is taken to be the text of a banner. Only the text is required:
+// the key defaults to the text, so identical messages replace each other rather than
+// queueing up, and the priority defaults to normal.
+func DecodeNotification(data []byte) (Notification, error) {
+	var m notificationMessage
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		if err := json.Unmarshal(trimmed, &m); err != nil {
+			return Notification{}, fmt.Errorf("invalid message: %w", err)
+		}
+	} else {
+		m.Text = string(trimmed)
+	}
+
+	n := Notification{
+		Key:      m.Key,
+		Text:     m.Text,
+		Priority: PriorityNormal,
+		Style:    m.Style,
+		Repeat:   m.Repeat,
+	}
+	if n.Text == "" {
+		return n, fmt.Errorf("message text is required")
+	}
+	if n.Key == "" {
+		n.Key = n.Text
+	}
+	if m.Priority != nil {
+		n.Priority = *m.Priority
+	}
+	if n.Style == "" {
+		n.Style = NotificationBanner
+	}
+
+	var err error
+	if m.TTL != "" {
+		if n.TTL, err = time.ParseDuration(m.TTL); err != nil {
+			return n, fmt.Errorf("invalid ttl: %w", err)
+		}
+	}
+	if m.Duration != "" {
+		if n.Duration, err = time.ParseDuration(m.Duration); err != nil {
+			return n, fmt.Errorf("invalid duration: %w", err)
+		}
+	}
+	if m.Colour != "" {
+		c, err := theme.ParseColour(m.Colour)
+		if err != nil {
+			return n, err
+		}
+		n.Colour = &c
+	}
+
+	return n, n.validate()
+}
+
+// queuedNotification is a notification waiting for, or taking, its turn on screen.
+type queuedNotification struct {
+	Notification
+	posted    time.Time
+	expires   time.Time
+	remaining int    // showings left, including the current one
+	afterStep uint64 // the page step the notification must wait to pass before showing
+	shownAt   time.Time
+	text      *Marquee // owned by the rendering goroutine
+}
+
+// activeNotification is a copy of the notification on screen, safe for the rendering
+// goroutine to use while the original is updated by another post.
+type activeNotification struct {
+	Notification
+	shownAt time.Time
+	text    *Marquee
+}
+
+// notificationQueue holds notifications in priority order. Notifications may be
+// posted from any goroutine; the rest is driven by the rendering goroutine.
+type notificationQueue struct {
+	mu      sync.Mutex
+	entries []*queuedNotification
+	showing *queuedNotification
+}
+
+// post adds the notification to the queue, replacing any with the same key.
+// step is the rotation's current page step.
+func (q *notificationQueue) post(n Notification, now time.Time, step uint64) {
+	if n.TTL == 0 {
+		n.TTL = defaultNotificationTTL
+	}
+	if n.Duration == 0 {
+		n.Duration = defaultNotificationDuration
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.showing != nil && q.showing.Key == n.Key {
+		// update the notification on screen without restarting it
+		q.showing.Notification = n
+		q.showing.expires = now.Add(n.TTL)
+		q.showing.remaining = n.Repeat + 1
+		return
+	}
+
+	e := &queuedNotification{
+		Notification: n,
+		posted:       now,
+		expires:      now.Add(n.TTL),
+		remaining:    n.Repeat + 1,
+		afterStep:    step,
+		text:         &Marquee{Align: bitmapfont.AlignCentre},
+	}
+	if n.Priority < PriorityHigh {
+		// wait for the rotation to move on, rather than cutting the page short
+		e.afterStep = step + 1
+	}
+
+	q.entries = slices.DeleteFunc(q.entries, func(x *queuedNotification) bool { return x.Key == n.Key })
+	q.entries = append(q.entries, e)
+	q.sort()
+}
+
+// dismiss removes the notification with the given key, on screen or queued.
+func (q *notificationQueue) dismiss(key string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.showing != nil && q.showing.Key == key {
+		q.showing = nil
+	}
+	q.entries = slices.DeleteFunc(q.entries, func(x *queuedNotification) bool { return x.Key == key })
+}
+
+// sort orders the queue by priority, oldest first within each priority.
+func (q *notificationQueue) sort() {
+	slices.SortStableFunc(q.entries, func(a, b *queuedNotification) int {
+		if a.Priority != b.Priority {
+			return int(b.Priority - a.Priority)
+		}
+		return a.posted.Compare(b.posted)
+	})
+}
+
+// onScreen returns the notification to draw now, if any, moving on from the one
+// on screen once it has been shown for long enough. step is the rotation's current
+// page step, and night is whether the night window is in effect.
+func (q *notificationQueue) onScreen(now time.Time, step uint64, night bool) (activeNotification, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.entries = slices.DeleteFunc(q.entries, func(x *queuedNotification) bool { return !now.Before(x.expires) })
+
+	if s := q.showing; s != nil {
+		switch {
+		case !now.Before(s.expires):
+			q.showing = nil
+		case now.Sub(s.shownAt) >= s.Duration:
+			q.showing = nil
+			s.remaining--
+			if s.remaining > 0 {
+				// show it again once the rotation has moved on
+				s.afterStep = step + 1
+				q.entries = append(q.entries, s)
+				q.sort()
+			}
+		}
+	}
+
+	if q.showing == nil {
+		for i, e := range q.entries {
+			if step < e.afterStep || (night && e.Priority < PriorityUrgent) {
+				continue
+			}
+			q.entries = slices.Delete(q.entries, i, i+1)
+			e.shownAt = now
+			e.text.Reset()
+			q.showing = e
+			break
+		}
+	}
+
+	if q.showing == nil {
+		return activeNotification{}, false
+	}
+	return activeNotification{
+		Notification: q.showing.Notification,
+		shownAt:      q.showing.shownAt,
+		text:         q.showing.text,
+	}, true
+}
+
+// active reports whether a notification is on screen.
+func (q *notificationQueue) active() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.showing != nil
+}
+
+// holding reports whether a notification has taken over the whole screen, in which
+// case the rotation waits for it to finish.
+func (q *notificationQueue) holding() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.showing != nil && q.showing.Style != NotificationBanner
+}
+
+// Notify queues a notification to be shown over the page rotation.
+// It is safe to call from any goroutine.
+func (r *ClockRenderer) Notify(n Notification) error {
+	if err := n.validate(); err != nil {
+		return err
+	}
+	r.notifications.post(n, time.Now(), r.pageSteps.Load())
+	return nil
+}
+
+// DismissNotification removes a notification from the screen or the queue.
+func (r *ClockRenderer) DismissNotification(key string) {
+	r.notifications.dismiss(key)
+}
+
+// drawNotification draws a full screen or flashing notification in place of the page.
+func (r *ClockRenderer) drawNotification(c *image.RGBA, n activeNotification, now time.Time) {
+	b := c.Bounds()
+	col := n.colour(r.FrameTheme())
+
+	if n.Style == NotificationFlash && now.Sub(n.shownAt)/flashInterval%2 == 0 {
+		draw.Draw(c, b, &image.Uniform{col}, image.Point{}, draw.Src)
+		col = color.RGBA{0, 0, 0, 255}
+	}
+
+	// text which fits on screen over a few lines is shown whole, rather than scrolled
+	wrapped := bitmapfont.Layout{Align: bitmapfont.AlignCentre, VAlign: bitmapfont.VAlignMiddle, Wrap: true, LineSpacing: 1}
+	font := r.Layout(c).Font
+	if wrapped.Fits(font, b.Size(), n.Text) {
+		r.DrawTextIn(c, b, n.Text, col, wrapped)
+		return
+	}
+
+	top := b.Min.Y + (b.Dy()-font.Height())/2
+	n.text.Draw(r, c, image.Rect(b.Min.X, top, b.Max.X, top+font.Height()), n.Text, col)
+}
+
+// drawBanner draws a notification across the bottom of the page, in a band one line
+// of text high.
+func (r *ClockRenderer) drawBanner(c *image.RGBA, n activeNotification) {
+	b := c.Bounds()
+	col := n.colour(r.FrameTheme())
+
+	band := image.Rect(b.Min.X, b.Max.Y-r.Layout(c).Font.Height(), b.Max.X, b.Max.Y)
+	bg := color.RGBA{col.R / 5, col.G / 5, col.B / 5, 255}
+	draw.Draw(c, band, &image.Uniform{bg}, image.Point{}, draw.Src)
+
+	n.text.Draw(r, c, image.Rect(band.Min.X+1, band.Min.Y+1, band.Max.X-1, band.Max.Y), n.Text, col)
+}
@@ -0,0 +1,118 @@
+package clock
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Page is a single screen in the clock's rotation.
+//
+// Init, Activate, Deactivate and Draw are all called from the rendering goroutine.
+// Ready is called from the rotation goroutine, so must be safe for concurrent use.
+type Page interface {
+	// Info describes the page to the renderer.
+	Info() PageInfo
+
+	// Init is called once, when the clock starts, before the page is first shown.
+	// A page which fails to initialise is left out of the rotation.
+	Init(ctx context.Context) error
+
+	// Ready reports whether the page has anything worth showing right now.
+	// Pages which are not ready are skipped by the rotation.
+	Ready() bool
+
+	// Activate is called when the page comes on screen, and Deactivate when it leaves.
+	Activate()
+	Deactivate()
+
+	// Draw renders the page content into the canvas, underneath the clock header
+	// unless the page is full screen.
+	Draw(c *image.RGBA) error
+}
+
+// PageInfo is the metadata a page provides about itself.
+type PageInfo struct {
+	// ID is the playlist ID the page was created from.
+	ID string
+	// Name is a human-readable name, distinguishing pages which share an ID.
+	Name string
+	// Duration is how long the page would like to stay on screen. Zero uses the
+	// default page interval. The playlist can override it.
+	Duration time.Duration
+	// Refresh is how often the page needs redrawing while it is on screen.
+	// Zero means the page is happy with the default frame rate.
+	Refresh time.Duration
+	// FullScreen pages draw over the whole canvas, so the clock header is left off.
+	FullScreen bool
+}
+
+// BasePage provides no-op lifecycle hooks, and is intended to be embedded by
+// pages which don't need them.
+type BasePage struct{}
+
+func (BasePage) Init(context.Context) error { return nil }
+func (BasePage) Ready() bool                { return true }
+func (BasePage) Activate()                  {}
+func (BasePage) Deactivate()                {}
+
+// PageFactory creates the pages for a single playlist entry. A factory may return
+// several pages (one per HA area, for example) or none at all if the data source
+// it depends on is not configured. Pages draw with the renderer it is given,
+// placing content with Layout and taking colours from FrameTheme.
+type PageFactory func(r *ClockRenderer, entry PlaylistEntry) ([]Page, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]PageFactory{}
+)
+
+// RegisterPage makes a page available to playlists under the given ID.
+// It is intended to be called from init functions, and panics if the ID is
+// already registered.
+func RegisterPage(id string, factory PageFactory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if factory == nil {
+		panic("clock: RegisterPage factory is nil")
+	}
+	if _, dup := registry[id]; dup {
+		panic(fmt.Sprintf("clock: RegisterPage called twice for page %q", id))
+	}
+	registry[id] = factory
+}
+
+func lookupPage(id string) (PageFactory, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	f, ok := registry[id]
+	return f, ok
+}
+
+// RegisteredPages returns the IDs of all registered pages, sorted.
+func RegisteredPages() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	ids := make([]string, 0, len(registry))
+	for id := range registry {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// rotationPage is a page in the rotation along with how long it stays on screen,
+// and how it is brought on screen.
+type rotationPage struct {
+	Page
+	id         string
+	name       string
+	duration   time.Duration
+	transition Transition
+}
@@ -3,24 +3,53 @@ package clock
 import (
 	"fmt"
 	"image"
-	"image/color"
+	"log"
+
+	"github.com/g-wilson/led/internal/bitmapfont"
 )
 
-var _ page = (*ClockRenderer)(nil).renderAirQuality
+func init() {
+	RegisterPage("airquality", func(r *ClockRenderer, entry PlaylistEntry) ([]Page, error) {
+		if r.airQuality == nil {
+			log.Printf("playlist: air quality agent unavailable, skipping %q", entry.ID)
+			return nil, nil
+		}
+		return []Page{&airQualityPage{r: r, id: entry.ID}}, nil
+	})
+}
+
+// airQualityPage shows the latest AQI, PM2.5 and O3 readings.
+type airQualityPage struct {
+	BasePage
+	r  *ClockRenderer
+	id string
+}
+
+func (p *airQualityPage) Info() PageInfo {
+	return PageInfo{ID: p.id, Name: "Air Quality"}
+}
+
+func (p *airQualityPage) Ready() bool {
+	return p.r.airQuality.Get().AQI.Value != ""
+}
+
+func (p *airQualityPage) Draw(c *image.RGBA) error {
+	air := p.r.airQuality.Get()
+	l := p.r.Layout(c)
 
-func (r *ClockRenderer) renderAirQuality(c *image.RGBA) error {
-	air := r.airQuality.Get()
+	// readings can come with long level names, so shorten anything which doesn't fit
+	layout := bitmapfont.Layout{Ellipsis: true}
 
-	r.addText(c, image.Point{X: 0, Y: 8}, "Air Quality", color.RGBA{180, 180, 180, 255})
+	p.r.DrawTextIn(c, l.Line(8), "Air Quality", p.r.FrameTheme().Heading, layout)
 
 	aqiText := fmt.Sprintf("AQI %s %s", air.AQI.Value, air.AQI.Level)
-	r.addText(c, image.Point{X: 0, Y: 14}, aqiText, air.AQI.Color)
+	p.r.DrawTextIn(c, l.Line(14), aqiText, air.AQI.Color, layout)
 
 	pm25Text := fmt.Sprintf("PM2.5 %s", air.PM25.Value)
-	r.addText(c, image.Point{X: 0, Y: 20}, pm25Text, air.PM25.Color)
+	p.r.DrawTextIn(c, l.Line(20), pm25Text, air.PM25.Color, layout)
 
 	o3Text := fmt.Sprintf("O3 %s", air.O3.Value)
-	r.addText(c, image.Point{X: 0, Y: 26}, o3Text, air.O3.Color)
+	p.r.DrawTextIn(c, l.Line(26), o3Text, air.O3.Color, layout)
 
 	return nil
 }
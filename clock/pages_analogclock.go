@@ -0,0 +1,154 @@
+package clock
+
+import (
+	"fmt"
+	"image"
+	"time"
+
+	"github.com/g-wilson/led/internal/bitmapfont"
+	"github.com/g-wilson/led/internal/gfx"
+	"github.com/g-wilson/led/internal/theme"
+)
+
+// Complications which can be shown beside the analog clock dial.
+const (
+	complicationDate        = "date"        // day of the week, and the date beneath
+	complicationTime        = "time"        // the digital time
+	complicationTemperature = "temperature" // the forecast temperature for this hour
+)
+
+type analogClockOptions struct {
+	// Seconds shows a second hand, on by default.
+	Seconds bool `yaml:"seconds"`
+	// Complications are shown in the space beside the dial, or beneath it on panels
+	// too narrow for them to fit beside it. The dial is centred if there are none.
+	Complications []string `yaml:"complications"`
+}
+
+func init() {
+	RegisterPage("analogclock", func(r *ClockRenderer, entry PlaylistEntry) ([]Page, error) {
+		opts := analogClockOptions{Seconds: true, Complications: []string{complicationDate}}
+		if err := entry.DecodeOptions(&opts); err != nil {
+			return nil, err
+		}
+		for _, name := range opts.Complications {
+			switch name {
+			case complicationDate, complicationTime, complicationTemperature:
+			default:
+				return nil, fmt.Errorf("unknown complication %q for page %q, must be date, time or temperature", name, entry.ID)
+			}
+		}
+		return []Page{&analogClockPage{r: r, id: entry.ID, opts: opts}}, nil
+	})
+}
+
+// analogClockPage draws a clock face as big as fits on the panel, with optional
+// complications beside or beneath it.
+type analogClockPage struct {
+	BasePage
+	r    *ClockRenderer
+	id   string
+	opts analogClockOptions
+}
+
+func (p *analogClockPage) Info() PageInfo {
+	info := PageInfo{ID: p.id, Name: "Analog Clock", FullScreen: true}
+	if p.opts.Seconds {
+		info.Refresh = secondsRefresh
+	}
+	return info
+}
+
+func (p *analogClockPage) Draw(c *image.RGBA) error {
+	now := time.Now().In(p.r.location)
+	b := c.Bounds()
+	lines := p.complications(now)
+	lineHeight := p.r.Layout(c).Font.Height()
+
+	dial, side := b, image.Rectangle{}
+	if len(lines) > 0 {
+		if b.Dx()-b.Dy() >= b.Dy()/2 {
+			dial = image.Rect(b.Min.X, b.Min.Y, b.Min.X+b.Dy(), b.Max.Y)
+			side = image.Rect(dial.Max.X, b.Min.Y, b.Max.X, b.Max.Y)
+		} else {
+			dial = image.Rect(b.Min.X, b.Min.Y, b.Max.X, b.Max.Y-len(lines)*lineHeight)
+			side = image.Rect(b.Min.X, dial.Max.Y, b.Max.X, b.Max.Y)
+		}
+	}
+
+	radius := float64(min(dial.Dx(), dial.Dy())) / 2
+	cx, cy := float64(dial.Min.X)+float64(dial.Dx())/2, float64(dial.Min.Y)+float64(dial.Dy())/2
+
+	t := p.r.FrameTheme()
+	canvas := gfx.New(c)
+	p.drawDial(canvas, t, cx, cy, radius)
+	p.drawHands(canvas, t, cx, cy, radius, now)
+
+	if len(lines) > 0 {
+		p.drawComplications(c, side, lines, lineHeight)
+	}
+
+	return nil
+}
+
+func (p *analogClockPage) drawDial(c *gfx.Canvas, t *theme.Theme, cx, cy, radius float64) {
+	c.Circle(cx, cy, radius-0.5, 1, t.Dial)
+
+	for hour := 0; hour < 12; hour++ {
+		length, col := 2.0, t.DialTick
+		if hour%3 == 0 {
+			length, col = 3.5, t.DialQuarter
+		}
+		angle := float64(hour) * 30
+		x0, y0 := gfx.Polar(cx, cy, radius-1.5, angle)
+		x1, y1 := gfx.Polar(cx, cy, radius-1.5-length, angle)
+		c.Line(x0, y0, x1, y1, 1, col)
+	}
+}
+
+func (p *analogClockPage) drawHands(c *gfx.Canvas, t *theme.Theme, cx, cy, radius float64, now time.Time) {
+	seconds := float64(now.Second())
+	minutes := float64(now.Minute()) + seconds/60
+	hours := float64(now.Hour()%12) + minutes/60
+
+	x, y := gfx.Polar(cx, cy, radius*0.5, hours*30)
+	c.Line(cx, cy, x, y, 2, t.Text)
+	x, y = gfx.Polar(cx, cy, radius*0.8, minutes*6)
+	c.Line(cx, cy, x, y, 1.2, t.Text)
+
+	if p.opts.Seconds {
+		// the second hand ticks rather than sweeping, like a quartz clock
+		x, y = gfx.Polar(cx, cy, radius*0.85, seconds*6)
+		c.Line(cx, cy, x, y, 0.8, t.SecondHand)
+	}
+
+	c.FillCircle(cx, cy, 1.2, t.Text)
+}
+
+// complications returns the lines of text for the complications.
+func (p *analogClockPage) complications(now time.Time) []string {
+	var lines []string
+	for _, name := range p.opts.Complications {
+		switch name {
+		case complicationDate:
+			lines = append(lines, now.Format("Mon"), now.Format("2 Jan"))
+		case complicationTime:
+			lines = append(lines, now.Format("15:04"))
+		case complicationTemperature:
+			if temp, ok := p.r.weather.GetTemperatureAt(now); ok {
+				lines = append(lines, fmt.Sprintf("%.foC", temp))
+			}
+		}
+	}
+	return lines
+}
+
+// drawComplications draws the complications' lines of text, centred in the area.
+func (p *analogClockPage) drawComplications(c *image.RGBA, area image.Rectangle, lines []string, lineHeight int) {
+	y := area.Min.Y + (area.Dy()-len(lines)*lineHeight)/2
+	layout := bitmapfont.Layout{Align: bitmapfont.AlignCentre, Ellipsis: true}
+	for _, line := range lines {
+		p.r.DrawTextIn(c, image.Rect(area.Min.X, y, area.Max.X, y+lineHeight), line, p.r.FrameTheme().Highlight, layout)
+		y += lineHeight
+	}
+}
@@ -0,0 +1,114 @@
+package clock
+
+import (
+	"fmt"
+	"image"
+	"time"
+
+	"github.com/g-wilson/led/internal/bitmapfont"
+	"github.com/g-wilson/led/internal/framestreamer"
+)
+
+// secondsRefresh redraws clock pages often enough that the seconds tick over on
+// time, rather than up to a second late as they would at the default frame rate.
+const secondsRefresh = framestreamer.TenFPS * time.Millisecond
+
+// Ways of showing the seconds on the big clock.
+const (
+	secondsColon = "colon" // blink the colon once a second
+	secondsBar   = "bar"   // fill a bar along the bottom over each minute
+	secondsNone  = "none"
+)
+
+type bigClockOptions struct {
+	// Hour12 shows the time on a 12-hour clock, with AM or PM.
+	Hour12 bool `yaml:"hour12"`
+	// Seconds is how the seconds are shown: colon, bar or none.
+	Seconds string `yaml:"seconds"`
+}
+
+func init() {
+	RegisterPage("bigclock", func(r *ClockRenderer, entry PlaylistEntry) ([]Page, error) {
+		opts := bigClockOptions{Seconds: secondsColon}
+		if err := entry.DecodeOptions(&opts); err != nil {
+			return nil, err
+		}
+		switch opts.Seconds {
+		case secondsColon, secondsBar, secondsNone:
+		default:
+			return nil, fmt.Errorf("invalid seconds option %q for page %q, must be colon, bar or none", opts.Seconds, entry.ID)
+		}
+		return []Page{&bigClockPage{r: r, id: entry.ID, opts: opts}}, nil
+	})
+}
+
+// bigClockPage fills the panel with the time in large digits, and the date beneath.
+type bigClockPage struct {
+	BasePage
+	r    *ClockRenderer
+	id   string
+	opts bigClockOptions
+}
+
+func (p *bigClockPage) Info() PageInfo {
+	return PageInfo{ID: p.id, Name: "Clock", Refresh: secondsRefresh, FullScreen: true}
+}
+
+func (p *bigClockPage) Draw(c *image.RGBA) error {
+	now := time.Now().In(p.r.location)
+	b := c.Bounds()
+	l := p.r.Layout(c)
+	t := p.r.FrameTheme()
+	digits := p.r.Font(FontLargeDigits)
+
+	hours, minutes := now.Format("15"), now.Format("04")
+	suffix := ""
+	if p.opts.Hour12 {
+		hours, suffix = now.Format("3"), now.Format("PM")
+	}
+
+	// the colon keeps its space while blinking, so the digits don't move
+	width := digits.Measure(hours + ":" + minutes)
+	if suffix != "" {
+		width += l.Font.Measure(suffix)
+	}
+	pos := image.Point{X: b.Min.X + bitmapfont.AlignOffset(width, b.Dx(), bitmapfont.AlignCentre), Y: l.Y(3)}
+
+	pen := digits.Draw(c, pos, hours, t.Text)
+	if p.opts.Seconds != secondsColon || now.Nanosecond() < int(time.Second/2) {
+		digits.Draw(c, pen, ":", t.Text)
+	}
+	pen.X += digits.Measure(":")
+	pen = digits.Draw(c, pen, minutes, t.Text)
+	if suffix != "" {
+		p.r.DrawText(c, pen, suffix, t.Text)
+	}
+
+	date := now.Format("Mon 2 Jan")
+	top := pos.Y + digits.Height() + 2
+	p.r.DrawTextIn(c, image.Rect(b.Min.X, top, b.Max.X, top+l.Font.Height()), date, t.Accent, bitmapfont.Layout{Align: bitmapfont.AlignCentre})
+
+	if p.opts.Seconds == secondsBar {
+		p.drawSecondsBar(c, now)
+	}
+
+	return nil
+}
+
+// drawSecondsBar fills a bar along the bottom of the canvas as the minute passes.
+func (p *bigClockPage) drawSecondsBar(c *image.RGBA, now time.Time) {
+	b := c.Bounds()
+	t := p.r.FrameTheme()
+	elapsed := time.Duration(now.Second())*time.Second + time.Duration(now.Nanosecond())
+	filled := b.Min.X + int(int64(b.Dx())*int64(elapsed)/int64(time.Minute))
+
+	for y := b.Max.Y - 2; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if x < filled {
+				c.SetRGBA(x, y, t.SecondsBar)
+			} else {
+				c.SetRGBA(x, y, t.SecondsBarOff)
+			}
+		}
+	}
+}
@@ -2,29 +2,62 @@ package clock
 
 import (
 	"image"
-	"image/color"
+	"time"
 
+	"github.com/g-wilson/led/internal/bitmapfont"
 	"github.com/g-wilson/led/internal/calendar"
-	"github.com/g-wilson/led/internal/huegradient"
+	"github.com/g-wilson/led/internal/framestreamer"
 
 	"golang.org/x/image/draw"
 )
 
-var (
-	colourEventName = huegradient.Gradient{BaseHue: 260}.Color(0)
-	colourCountdown = color.RGBA{215, 0, 0, 255}
-)
+func init() {
+	RegisterPage("countdown", func(r *ClockRenderer, entry PlaylistEntry) ([]Page, error) {
+		return []Page{&countdownPage{r: r, id: entry.ID, name: Marquee{Align: bitmapfont.AlignCentre}}}, nil
+	})
+}
+
+// countdownPage counts down to the next calendar event.
+type countdownPage struct {
+	BasePage
+	r  *ClockRenderer
+	id string
+
+	name  Marquee
+	start time.Time // when the page came on screen, for playing animated images
+}
+
+func (p *countdownPage) Info() PageInfo {
+	refresh := marqueeRefreshFor(&p.name)
+	// redraw when an animated image next changes frame, if that's sooner
+	if event := calendar.GetNextEvent(); event != nil && event.Image != nil {
+		if next := event.Image.NextFrame(time.Since(p.start)); next > 0 && (refresh == 0 || next < refresh) {
+			refresh = max(next, framestreamer.ThirtyFPS*time.Millisecond)
+		}
+	}
+	return PageInfo{ID: p.id, Name: "Countdown", Refresh: refresh}
+}
 
-var _ page = (*ClockRenderer)(nil).renderCountdown
+func (p *countdownPage) Activate() {
+	p.name.Reset()
+	p.start = time.Now()
+}
+
+func (p *countdownPage) Ready() bool {
+	return calendar.GetNextEvent() != nil
+}
 
-func (r *ClockRenderer) renderCountdown(c *image.RGBA) error {
+func (p *countdownPage) Draw(c *image.RGBA) error {
 	if event := calendar.GetNextEvent(); event != nil {
+		l, t := p.r.Layout(c), p.r.FrameTheme()
 		if event.Image != nil {
-			draw.Draw(c, c.Bounds(), event.Image, image.Point{X: -44, Y: -9}, draw.Over)
+			// the image sits in the top right, clear of the centred text on wider panels
+			frame := event.Image.Frame(time.Since(p.start))
+			pos := image.Pt(c.Bounds().Max.X-l.Scale(referenceWidth-44), l.Y(9))
+			draw.Draw(c, frame.Bounds().Add(pos), frame, image.Point{}, draw.Over)
 		}
-		halfway := 32 - int(float64((4*len(event.Name))/2))
-		r.addText(c, image.Point{X: halfway, Y: 15}, event.Name, colourEventName)
-		r.addText(c, image.Point{X: 10, Y: 22}, formatDuration(event.Until()), colourCountdown)
+		p.name.Draw(p.r, c, l.Rect(0, 15, referenceWidth, 22), event.Name, t.Highlight)
+		p.r.DrawTextIn(c, l.Line(22), formatDuration(event.Until()), t.Alert, bitmapfont.Layout{Align: bitmapfont.AlignCentre})
 	}
 	return nil
 }
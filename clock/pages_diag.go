@@ -6,65 +6,91 @@ import (
 	"image/color"
 	"time"
 
+	"github.com/g-wilson/led/internal/bitmapfont"
+	"github.com/g-wilson/led/internal/chart"
 	"github.com/g-wilson/led/internal/diagnostics"
-	"github.com/g-wilson/led/internal/huegradient"
+	"github.com/g-wilson/led/internal/gfx"
+	"github.com/g-wilson/led/internal/history"
+	"github.com/g-wilson/led/internal/theme"
 )
 
-var (
-	diagGreen  = huegradient.Gradient{BaseHue: 150}.Color(0)
-	diagYellow = huegradient.Gradient{BaseHue: 100}.Color(0)
-	diagOrange = huegradient.Gradient{BaseHue: 50}.Color(0)
-	diagRed    = huegradient.Gradient{BaseHue: 26}.Color(0)
-)
+// diagPingScale is the scale of the ping chart, 0-200ms, over which it is coloured
+// to match the ping levels
+var diagPingScale = chart.Scale{Min: 0, Max: 200}
+
+func init() {
+	RegisterPage("diag", func(r *ClockRenderer, entry PlaylistEntry) ([]Page, error) {
+		return []Page{&diagPage{r: r, id: entry.ID}}, nil
+	})
+}
+
+// diagPage shows network health from the diagnostics agent.
+type diagPage struct {
+	BasePage
+	r  *ClockRenderer
+	id string
+}
+
+func (p *diagPage) Info() PageInfo {
+	return PageInfo{ID: p.id, Name: "Diagnostics"}
+}
 
-var _ page = (*ClockRenderer)(nil).renderDiag
+func (p *diagPage) Draw(c *image.RGBA) error {
+	status := p.r.diagnostics.GetStatus()
+	t := p.r.FrameTheme()
+	sinceText, sinceColor := diagSinceText(status, t)
+	pingText, pingColor := diagPingText(status, t)
 
-func (r *ClockRenderer) renderDiag(c *image.RGBA) error {
-	status := r.diagnostics.GetStatus()
-	sinceText, sinceColor := diagSinceText(status)
-	pingText, pingColor := diagPingText(status)
+	l := p.r.Layout(c)
+	layout := bitmapfont.Layout{Ellipsis: true}
+	p.r.DrawTextIn(c, l.Line(10).Inset(1), sinceText, sinceColor, layout)
+	p.r.DrawTextIn(c, l.Line(18).Inset(1), pingText, pingColor, layout)
 
-	r.addText(c, image.Point{X: 1, Y: 10}, sinceText, sinceColor)
-	r.addText(c, image.Point{X: 1, Y: 18}, pingText, pingColor)
+	// the last day of pings along the bottom, taller if there's room below the text
+	b := c.Bounds()
+	pings := history.Values(p.r.diagnostics.GetPingHistory().Samples())
+	area := image.Rect(b.Min.X+1, l.Y(referenceHeight-6), b.Max.X-1, b.Max.Y)
+	ramp := chart.Gradient(t.Good, t.Fair, t.Warning, t.Bad)
+	chart.Sparkline(gfx.New(c), area, pings, chart.Options{Scale: diagPingScale, Ramp: ramp})
 
 	return nil
 }
 
-func diagSinceText(status diagnostics.Status) (string, color.RGBA) {
+func diagSinceText(status diagnostics.Status, t *theme.Theme) (string, color.RGBA) {
 	if status.LastHealthyAt.IsZero() {
-		return "Last ok never", diagRed
+		return "Last ok never", t.Bad
 	}
 
 	since := time.Since(status.LastHealthyAt)
 	sinceText := fmt.Sprintf("Last ok %s", formatShortDuration(since))
 
 	if status.IsStale(time.Now()) {
-		return sinceText, diagRed
+		return sinceText, t.Bad
 	}
 
-	return sinceText, diagGreen
+	return sinceText, t.Good
 }
 
-func diagPingText(status diagnostics.Status) (string, color.RGBA) {
+func diagPingText(status diagnostics.Status, t *theme.Theme) (string, color.RGBA) {
 	if !status.LastPingOk {
-		return "Ping n/a", diagRed
+		return "Ping n/a", t.Bad
 	}
 
 	pingText := fmt.Sprintf("Ping %dms", status.LastPing.Milliseconds())
 	level := status.PingLevel()
 
-	return pingText, diagPingColor(level)
+	return pingText, diagPingColor(level, t)
 }
 
-func diagPingColor(level diagnostics.PingLevel) color.RGBA {
+func diagPingColor(level diagnostics.PingLevel, t *theme.Theme) color.RGBA {
 	switch level {
 	case diagnostics.PingLevelGreen:
-		return diagGreen
+		return t.Good
 	case diagnostics.PingLevelYellow:
-		return diagYellow
+		return t.Fair
 	case diagnostics.PingLevelOrange:
-		return diagOrange
+		return t.Warning
 	default:
-		return diagRed
+		return t.Bad
 	}
 }
@@ -0,0 +1,180 @@
+package clock
+
+import (
+	"fmt"
+	"image"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/g-wilson/led/internal/bitmapfont"
+	"github.com/g-wilson/led/internal/chart"
+	"github.com/g-wilson/led/internal/gfx"
+	"github.com/g-wilson/led/internal/history"
+)
+
+// Chart styles for the graph page.
+const (
+	graphSparkline = "sparkline"
+	graphBars      = "bars"
+	graphBand      = "band"
+	graphGauge     = "gauge"
+)
+
+const defaultGraphPeriod = history.Period
+
+type graphPageOptions struct {
+	// Source is ping, aqi or the entity ID of a Home Assistant sensor.
+	Source string `yaml:"source"`
+	// Style is sparkline, bars, band or gauge.
+	Style string `yaml:"style"`
+	// Title defaults to a name for the source.
+	Title string `yaml:"title"`
+	// Period is how far back the chart goes.
+	Period time.Duration `yaml:"period"`
+	// Min and Max fix the scale, which otherwise fits the data. A gauge needs them.
+	Min float64 `yaml:"min"`
+	Max float64 `yaml:"max"`
+}
+
+func init() {
+	RegisterPage("graph", newGraphPage)
+}
+
+func newGraphPage(r *ClockRenderer, entry PlaylistEntry) ([]Page, error) {
+	opts := graphPageOptions{Style: graphSparkline, Period: defaultGraphPeriod}
+	if err := entry.DecodeOptions(&opts); err != nil {
+		return nil, err
+	}
+
+	switch opts.Style {
+	case graphSparkline, graphBars, graphBand:
+	case graphGauge:
+		if opts.Max <= opts.Min {
+			return nil, fmt.Errorf("gauge for page %q needs a min and max", entry.ID)
+		}
+	default:
+		return nil, fmt.Errorf("invalid style %q for page %q, must be sparkline, bars, band or gauge", opts.Style, entry.ID)
+	}
+
+	p := &graphPage{r: r, id: entry.ID, opts: opts}
+
+	switch opts.Source {
+	case "":
+		return nil, fmt.Errorf("page %q needs a source", entry.ID)
+
+	case "ping":
+		p.title, p.unit = "Ping", "ms"
+		p.series = func() (*history.Series, bool) { return r.diagnostics.GetPingHistory(), true }
+
+	case "aqi":
+		if r.airQuality == nil {
+			log.Printf("playlist: air quality agent unavailable, skipping %q", entry.ID)
+			return nil, nil
+		}
+		p.title = "AQI"
+		p.series = func() (*history.Series, bool) { return r.airQuality.GetAQIHistory(), true }
+
+	default:
+		if r.sensors == nil {
+			log.Printf("playlist: sensors agent unavailable, skipping %q", entry.ID)
+			return nil, nil
+		}
+		entityID := opts.Source
+		p.title = entityID
+		if s, ok := r.sensors.GetSensor(entityID); ok {
+			p.title, p.unit = s.Name, s.Unit
+		}
+		p.series = func() (*history.Series, bool) { return r.sensors.GetSensorHistory(entityID) }
+	}
+
+	if opts.Title != "" {
+		p.title = opts.Title
+	}
+
+	return []Page{p}, nil
+}
+
+// graphPage charts the recent history of a reading, with its latest value.
+type graphPage struct {
+	BasePage
+	r      *ClockRenderer
+	id     string
+	opts   graphPageOptions
+	title  string
+	unit   string
+	series func() (*history.Series, bool)
+
+	name Marquee
+}
+
+func (p *graphPage) Info() PageInfo {
+	return PageInfo{ID: p.id, Name: p.title, Refresh: marqueeRefreshFor(&p.name)}
+}
+
+func (p *graphPage) Activate() {
+	p.name.Reset()
+}
+
+func (p *graphPage) Ready() bool {
+	s, ok := p.series()
+	return ok && s.Len() > 0
+}
+
+func (p *graphPage) Draw(c *image.RGBA) error {
+	series, ok := p.series()
+	if !ok {
+		return nil
+	}
+	latest, ok := series.Latest()
+	if !ok {
+		return nil
+	}
+
+	// the chart fills the space below the title, however much there is
+	t := p.r.FrameTheme()
+	b := c.Bounds()
+	l := p.r.Layout(c)
+	lineHeight := l.Font.Height()
+	title := l.Body()
+	title.Max.Y = title.Min.Y + lineHeight
+	value := formatReading(latest.Value) + p.unit
+	valueWidth := l.Font.Measure(value)
+	p.r.DrawTextIn(c, title, value, t.Text, bitmapfont.Layout{Align: bitmapfont.AlignRight})
+	p.name.Draw(p.r, c, image.Rect(title.Min.X, title.Min.Y, title.Max.X-valueWidth-2, title.Max.Y), p.title, t.Accent)
+
+	values := history.Values(series.Since(time.Now().Add(-p.opts.Period)))
+	opts := chart.Options{
+		Scale: chart.Scale{Min: p.opts.Min, Max: p.opts.Max, IncludeZero: p.opts.Style == graphBars},
+		Ramp:  chart.Gradient(t.Chart.Stops(4)...),
+	}
+	canvas := gfx.New(c)
+	area := image.Rect(b.Min.X, title.Max.Y, b.Max.X, b.Max.Y)
+
+	switch p.opts.Style {
+	case graphSparkline:
+		chart.Sparkline(canvas, area, values, opts)
+	case graphBars:
+		chart.Bars(canvas, area, values, opts)
+	case graphBand:
+		chart.Band(canvas, area, values, opts)
+	case graphGauge:
+		// the bar and its labels sit on rows of the reference layout, as they don't
+		// stretch to fill the space
+		opts.Background = t.Track
+		bar := image.Rect(area.Min.X+l.Scale(1), l.Y(16), area.Max.X-l.Scale(1), l.Y(22))
+		chart.Gauge(canvas, bar, latest.Value, opts)
+
+		labels := l.Line(24).Inset(1)
+		p.r.DrawTextIn(c, labels, formatReading(p.opts.Min), t.Muted, bitmapfont.Layout{})
+		p.r.DrawTextIn(c, labels, formatReading(p.opts.Max), t.Muted, bitmapfont.Layout{Align: bitmapfont.AlignRight})
+	}
+
+	return nil
+}
+
+// formatReading shows a value to one decimal place, leaving off ".0".
+func formatReading(v float64) string {
+	return strings.TrimSuffix(strconv.FormatFloat(v, 'f', 1, 64), ".0")
+}
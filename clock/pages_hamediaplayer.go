@@ -2,49 +2,83 @@ package clock
 
 import (
 	"image"
-	"image/color"
+	"log"
+	"time"
 
+	"github.com/g-wilson/led/internal/bitmapfont"
 	"github.com/g-wilson/led/internal/hamediaplayer"
-	"github.com/g-wilson/led/internal/huegradient"
 )
 
-var mediaGradient = huegradient.Gradient{BaseHue: 160, Step: 75}
+// nowPlayingDuration keeps the now playing page up for longer than the default,
+// as there's more to read
+const nowPlayingDuration = 10 * time.Second
 
-var nowPlayingTitle = color.RGBA{215, 0, 88, 255}
-var nowPlayingMuted = color.RGBA{100, 100, 100, 255}
+func init() {
+	RegisterPage("nowplaying", func(r *ClockRenderer, entry PlaylistEntry) ([]Page, error) {
+		if r.mediaPlayer == nil {
+			log.Printf("playlist: media player agent unavailable, skipping %q", entry.ID)
+			return nil, nil
+		}
+		return []Page{&nowPlayingPage{r: r, id: entry.ID}}, nil
+	})
+}
+
+// nowPlayingPage shows the track playing on the first active media player.
+type nowPlayingPage struct {
+	BasePage
+	r  *ClockRenderer
+	id string
 
-var _ page = (*ClockRenderer)(nil).renderNowPlaying
+	player, artist, title, album Marquee
+}
 
-func (r *ClockRenderer) renderNowPlaying(c *image.RGBA) error {
-	player, ok := r.mediaPlayer.GetPlayingPlayer()
+func (p *nowPlayingPage) Info() PageInfo {
+	return PageInfo{
+		ID:       p.id,
+		Name:     "Now Playing",
+		Duration: nowPlayingDuration,
+		Refresh:  marqueeRefreshFor(&p.player, &p.artist, &p.title, &p.album),
+	}
+}
+
+func (p *nowPlayingPage) Activate() {
+	p.player.Reset()
+	p.artist.Reset()
+	p.title.Reset()
+	p.album.Reset()
+}
 
+func (p *nowPlayingPage) Ready() bool {
+	_, ok := p.r.mediaPlayer.GetPlayingPlayer()
+	return ok
+}
+
+func (p *nowPlayingPage) Draw(c *image.RGBA) error {
+	player, ok := p.r.mediaPlayer.GetPlayingPlayer()
+	l, t := p.r.Layout(c), p.r.FrameTheme()
+
+	// playback can stop while the page is on screen
 	if !ok {
-		r.addText(c, image.Point{X: 0, Y: 12}, ">Nothing playing", nowPlayingMuted)
+		p.r.DrawTextIn(c, l.Line(12), ">Nothing playing", t.Muted, bitmapfont.Layout{Ellipsis: true})
 		return nil
 	}
 
-	r.addText(c, image.Point{X: 0, Y: 5}, ">>"+truncateN(player.FriendlyName, 14), nowPlayingTitle)
-	renderMediaInfo(r, c, player)
+	p.r.DrawTextIn(c, l.Rect(0, 5, 8, 12), ">>", t.Accent, bitmapfont.Layout{})
+	p.player.Draw(p.r, c, l.Rect(8, 5, referenceWidth, 12), player.FriendlyName, t.Accent)
+	p.drawMediaInfo(c, l, player)
 
 	return nil
 }
 
-func renderMediaInfo(r *ClockRenderer, c *image.RGBA, player hamediaplayer.MediaPlayerState) {
+func (p *nowPlayingPage) drawMediaInfo(c *image.RGBA, l Layout, player hamediaplayer.MediaPlayerState) {
+	palette := p.r.FrameTheme().Media
 	if player.MediaArtist != "" {
-		r.addText(c, image.Point{X: 0, Y: 12}, truncateN(player.MediaArtist, 16), mediaGradient.Color(0))
+		p.artist.Draw(p.r, c, l.Rect(0, 12, referenceWidth, 19), player.MediaArtist, palette.Color(0))
 	}
 	if player.MediaTitle != "" {
-		r.addText(c, image.Point{X: 0, Y: 19}, truncateN(player.MediaTitle, 16), mediaGradient.Color(1))
+		p.title.Draw(p.r, c, l.Rect(0, 19, referenceWidth, 26), player.MediaTitle, palette.Color(1))
 	}
 	if player.MediaAlbum != "" {
-		r.addText(c, image.Point{X: 0, Y: 25}, truncateN(player.MediaAlbum, 16), mediaGradient.Color(2))
-	}
-}
-
-func truncateN(s string, n int) string {
-	r := []rune(s)
-	if len(r) > n {
-		return string(r[:n])
+		p.album.Draw(p.r, c, l.Rect(0, 25, referenceWidth, 32), player.MediaAlbum, palette.Color(2))
 	}
-	return s
 }
@@ -3,21 +3,104 @@ package clock
 import (
 	"fmt"
 	"image"
-	"image/color"
+	"log"
 	"strings"
-
-	"github.com/g-wilson/led/internal/huegradient"
 )
 
-var sensorGradient = huegradient.Gradient{BaseHue: 60, Step: 50}
+func init() {
+	RegisterPage("areas", newAreaPages)
+}
+
+type areaPageOptions struct {
+	// Areas limits the pages to the named HA areas, in the given order.
+	// All areas are shown when empty.
+	Areas []string `yaml:"areas"`
+}
+
+func newAreaPages(r *ClockRenderer, entry PlaylistEntry) ([]Page, error) {
+	if r.sensors == nil {
+		log.Printf("playlist: sensors agent unavailable, skipping %q", entry.ID)
+		return nil, nil
+	}
+
+	opts := areaPageOptions{}
+	if err := entry.DecodeOptions(&opts); err != nil {
+		return nil, err
+	}
+
+	areas := opts.Areas
+	if len(areas) == 0 {
+		areas = r.sensors.GetAreas()
+	}
+
+	pages := make([]Page, 0, len(areas))
+	for _, areaName := range areas {
+		if _, ok := r.sensors.GetArea(areaName); !ok {
+			log.Printf("playlist: unknown HA area %q, skipping", areaName)
+			continue
+		}
+		pages = append(pages, &areaPage{r: r, id: entry.ID, area: areaName})
+	}
+
+	return pages, nil
+}
+
+// areaPage shows the sensor readings for a single HA area.
+type areaPage struct {
+	BasePage
+	r    *ClockRenderer
+	id   string
+	area string
+
+	title Marquee
+	lines []*Marquee // one per sensor, grown as sensors appear
+}
+
+func (p *areaPage) Info() PageInfo {
+	refresh := marqueeRefreshFor(&p.title)
+	if refresh == 0 {
+		refresh = marqueeRefreshFor(p.lines...)
+	}
+	return PageInfo{ID: p.id, Name: p.area, Refresh: refresh}
+}
+
+func (p *areaPage) Activate() {
+	p.title.Reset()
+	for _, m := range p.lines {
+		m.Reset()
+	}
+}
+
+func (p *areaPage) Ready() bool {
+	as, ok := p.r.sensors.GetArea(p.area)
+	return ok && len(as.Sensors) > 0
+}
+
+func (p *areaPage) Draw(c *image.RGBA) error {
+	// the list starts below the header, running on down taller panels and into
+	// columns side by side on wider ones
+	l, t := p.r.Layout(c), p.r.FrameTheme()
+	body := l.Body()
+	lineHeight, spacing := l.Scale(7), l.Scale(6)
+	p.title.Draw(p.r, c, image.Rect(body.Min.X, body.Min.Y, body.Max.X, body.Min.Y+lineHeight), p.area, t.Accent)
 
-func (r *ClockRenderer) renderArea(c *image.RGBA, area string) error {
-	r.addText(c, image.Point{X: 0, Y: 5}, area, color.RGBA{215, 0, 88, 255})
+	list := body
+	list.Min.Y += lineHeight
+	cols := l.Columns(list)
+	rows := (list.Dy() + spacing - 1) / spacing
 
-	if as, ok := r.sensors.GetArea(area); ok {
+	if as, ok := p.r.sensors.GetArea(p.area); ok {
 		for i, s := range as.Sensors {
-			y := 12 + (i * 6)
-			r.addText(c, image.Point{X: 0, Y: y}, fmt.Sprintf("%s %s%s", shortenSensorName(s.Name), s.State, s.Unit), sensorGradient.Color(i))
+			if i >= rows*len(cols) {
+				break
+			}
+			if i == len(p.lines) {
+				p.lines = append(p.lines, &Marquee{})
+			}
+			col := cols[i/rows]
+			y := col.Min.Y + (i%rows)*spacing
+			text := fmt.Sprintf("%s %s%s", shortenSensorName(s.Name), s.State, s.Unit)
+			p.lines[i].Draw(p.r, c, image.Rect(col.Min.X, y, col.Max.X, y+lineHeight), text, t.Sensors.Color(i))
 		}
 	}
 
@@ -6,14 +6,13 @@ import (
 	"math"
 	"time"
 
+	"github.com/g-wilson/led/internal/bitmapfont"
+	"github.com/g-wilson/led/internal/gfx"
+
 	"github.com/soniakeys/meeus/v3/julian"
 	"github.com/soniakeys/meeus/v3/moonillum"
 )
 
-var colourMoon = color.RGBA{50, 50, 70, 255}
-var litColour = color.RGBA{210, 215, 230, 255}
-var darkColour = color.RGBA{25, 25, 45, 255}
-
 func moonPhaseIllumination(t time.Time) (illum float64, waxing bool) {
 	jde := julian.TimeToJD(t)
 	angle := moonillum.PhaseAngle3(jde)
@@ -48,45 +47,61 @@ func moonPhaseName(illum float64, waxing bool) string {
 	}
 }
 
-func drawMoonDisc(c *image.RGBA, centre image.Point, radius int, illum float64, waxing bool) {
-	r2 := radius * radius
-	for dy := -radius; dy <= radius; dy++ {
-		hw := math.Sqrt(float64(r2 - dy*dy))
-		termX := hw * (1 - 2*illum)
-		for dx := -radius; dx <= radius; dx++ {
-			if dx*dx+dy*dy > r2 {
-				continue
-			}
-			px := centre.X + dx
-			py := centre.Y + dy
-			if !image.Pt(px, py).In(c.Bounds()) {
-				continue
-			}
-			var lit bool
-			if waxing {
-				lit = float64(dx) >= termX
-			} else {
-				lit = float64(dx) <= -termX
-			}
-			if lit {
-				c.SetRGBA(px, py, litColour)
-			} else {
-				c.SetRGBA(px, py, darkColour)
-			}
+// drawMoonDisc draws the moon centred on the centre pixel, lit from the right while
+// waxing and from the left while waning, with the terminator curving across it.
+func drawMoonDisc(c *image.RGBA, centre image.Point, radius int, illum float64, waxing bool, lit, dark color.RGBA) {
+	canvas := gfx.New(c)
+	cx, cy := float64(centre.X)+0.5, float64(centre.Y)+0.5
+	r := float64(radius) + 0.5
+
+	canvas.FillCircle(cx, cy, r, dark)
+	area := image.Rect(centre.X-radius-1, centre.Y-radius-1, centre.X+radius+2, centre.Y+radius+2)
+	canvas.Fill(area, func(x, y float64) bool {
+		dx, dy := x-cx, y-cy
+		if dx*dx+dy*dy > r*r {
+			return false
 		}
-	}
+		termX := math.Sqrt(r*r-dy*dy) * (1 - 2*illum)
+		if waxing {
+			return dx >= termX
+		}
+		return dx <= -termX
+	}, lit)
 }
 
-var _ page = (*ClockRenderer)(nil).renderMoon
+func init() {
+	RegisterPage("moon", func(r *ClockRenderer, entry PlaylistEntry) ([]Page, error) {
+		return []Page{&moonPage{r: r, id: entry.ID}}, nil
+	})
+}
 
-func (r *ClockRenderer) renderMoon(c *image.RGBA) error {
+// moonPage shows the current phase of the moon.
+type moonPage struct {
+	BasePage
+	r  *ClockRenderer
+	id string
+}
+
+func (p *moonPage) Info() PageInfo {
+	return PageInfo{ID: p.id, Name: "Moon"}
+}
+
+func (p *moonPage) Draw(c *image.RGBA) error {
 	illum, waxing := moonPhaseIllumination(time.Now())
 	name := moonPhaseName(illum, waxing)
+	t := p.r.FrameTheme()
+
+	// the name sits along the bottom, with the moon as big as fits in the space above
+	b := c.Bounds()
+	l := p.r.Layout(c)
+	text := image.Rect(b.Min.X, b.Max.Y-l.Scale(6), b.Max.X, b.Max.Y-l.Scale(6)+l.Font.Height())
+	sky := image.Rect(b.Min.X, l.Body().Min.Y, b.Max.X, text.Min.Y)
 
-	drawMoonDisc(c, image.Point{X: 32, Y: 16}, 9, illum, waxing)
+	radius := min(sky.Dy()-3, sky.Dx()-4) / 2
+	centre := image.Point{X: b.Min.X + b.Dx()/2, Y: sky.Min.Y + radius + 2}
+	drawMoonDisc(c, centre, radius, illum, waxing, t.MoonLit, t.MoonDark)
 
-	centreX := 32 - (4*len(name))/2
-	r.addText(c, image.Point{X: centreX, Y: 26}, name, colourMoon)
+	p.r.DrawTextIn(c, text, name, t.MoonName, bitmapfont.Layout{Align: bitmapfont.AlignCentre})
 
 	return nil
 }
@@ -4,84 +4,137 @@ import (
 	"fmt"
 	"image"
 	"image/color"
+	"time"
 
-	"github.com/g-wilson/led/internal/huegradient"
+	"github.com/g-wilson/led/internal/bitmapfont"
 	"github.com/g-wilson/led/internal/weather"
 )
 
-var (
-	colourDayName = color.RGBA{215, 0, 88, 255}
+func init() {
+	RegisterPage("today", func(r *ClockRenderer, entry PlaylistEntry) ([]Page, error) {
+		return []Page{&forecastPage{r: r, id: entry.ID, name: "Today", forecast: r.weather.GetToday}}, nil
+	})
+	RegisterPage("tomorrow", func(r *ClockRenderer, entry PlaylistEntry) ([]Page, error) {
+		return []Page{&forecastPage{r: r, id: entry.ID, name: "Tomorrow", forecast: r.weather.GetTomorrow}}, nil
+	})
+	RegisterPage("daylight", func(r *ClockRenderer, entry PlaylistEntry) ([]Page, error) {
+		return []Page{&daylightPage{r: r, id: entry.ID}}, nil
+	})
+}
 
-	colourTempLow  = huegradient.Gradient{BaseHue: 240}.Color(0)
-	colourTempHigh = huegradient.Gradient{BaseHue: 80}.Color(0)
+// forecastPage shows the weather forecast for a single day.
+type forecastPage struct {
+	BasePage
+	r        *ClockRenderer
+	id       string
+	name     string
+	forecast func() weather.DayWeather
+	start    time.Time // when the page came on screen, for animating the icon
+}
 
-	colourSunGradient  = huegradient.Gradient{BaseHue: 40, Step: 40}
-	colourMoonGradient = huegradient.Gradient{BaseHue: 280, Step: 40}
-)
+func (p *forecastPage) Info() PageInfo {
+	return PageInfo{ID: p.id, Name: p.name, Refresh: p.r.weatherRefresh(p.forecast())}
+}
 
-var _ page = (*ClockRenderer)(nil).renderToday
-var _ page = (*ClockRenderer)(nil).renderTomorrow
-var _ page = (*ClockRenderer)(nil).renderDaylight
+func (p *forecastPage) Activate() {
+	p.start = time.Now()
+}
 
-func (r *ClockRenderer) renderToday(c *image.RGBA) error {
-	w := r.weather.GetToday()
-	r.addText(c, image.Point{X: 0, Y: 8}, "Today", colourDayName)
-	r.renderWeather(c, w)
+func (p *forecastPage) Draw(c *image.RGBA) error {
+	w := p.forecast()
+	p.r.DrawTextIn(c, p.r.Layout(c).Line(8), p.name, p.r.FrameTheme().Accent, bitmapfont.Layout{Ellipsis: true})
+	p.r.renderWeather(c, w, p.start)
 	return nil
 }
 
-func (r *ClockRenderer) renderTomorrow(c *image.RGBA) error {
-	w := r.weather.GetTomorrow()
-	r.addText(c, image.Point{X: 0, Y: 8}, "Tomorrow", colourDayName)
-	r.renderWeather(c, w)
-	return nil
+// daylightPage shows today's sun and moon rise and set times.
+type daylightPage struct {
+	BasePage
+	r  *ClockRenderer
+	id string
+}
+
+func (p *daylightPage) Info() PageInfo {
+	return PageInfo{ID: p.id, Name: "Daylight"}
+}
+
+func (p *daylightPage) Ready() bool {
+	return !p.r.weather.GetToday().SunriseTime.IsZero()
 }
 
-func (r *ClockRenderer) renderDaylight(c *image.RGBA) error {
-	w := r.weather.GetToday()
-	xOffset := 2
+func (p *daylightPage) Draw(c *image.RGBA) error {
+	w := p.r.weather.GetToday()
+	l, t := p.r.Layout(c), p.r.FrameTheme()
 
-	sunrise := w.SunriseTime.In(r.location).Format("15:04")
-	sunset := w.SunsetTime.In(r.location).Format("15:04")
-	r.addText(c, image.Point{X: xOffset, Y: 8}, fmt.Sprintf(" Sunrise %s", sunrise), colourSunGradient.Color(0))
-	r.addText(c, image.Point{X: xOffset, Y: 14}, fmt.Sprintf("  Sunset %s", sunset), colourSunGradient.Color(1))
+	// on wide panels the moon's times go beside the sun's, rather than below them
+	sun, moon, moonY := l.Bounds, l.Bounds, 20
+	if cols := l.Columns(l.Bounds); len(cols) > 1 {
+		sun, moon, moonY = cols[0], cols[1], 8
+	}
+
+	p.drawTime(c, l, sun, 8, "Sunrise", w.SunriseTime, t.Sun.Color(0))
+	p.drawTime(c, l, sun, 14, "Sunset", w.SunsetTime, t.Sun.Color(1))
 
 	if !w.MoonriseTime.IsZero() {
-		moonrise := w.MoonriseTime.In(r.location).Format("15:04")
-		r.addText(c, image.Point{X: xOffset, Y: 20}, fmt.Sprintf("Moonrise %s", moonrise), colourMoonGradient.Color(0))
+		p.drawTime(c, l, moon, moonY, "Moonrise", w.MoonriseTime, t.Moon.Color(0))
 	}
 	if !w.MoonsetTime.IsZero() {
-		moonset := w.MoonsetTime.In(r.location).Format("15:04")
-		r.addText(c, image.Point{X: xOffset, Y: 26}, fmt.Sprintf(" Moonset %s", moonset), colourMoonGradient.Color(1))
+		p.drawTime(c, l, moon, moonY+6, "Moonset", w.MoonsetTime, t.Moon.Color(1))
 	}
 
 	return nil
 }
 
-func (r *ClockRenderer) renderWeather(c *image.RGBA, w weather.DayWeather) {
+// drawTime draws a label right-aligned to a column, so the times line up after it.
+func (p *daylightPage) drawTime(c *image.RGBA, l Layout, col image.Rectangle, y int, label string, t time.Time, colour color.RGBA) {
+	const labelEnd, timeStart = 34, 38
+
+	line := l.Line(y)
+	p.r.DrawTextIn(c, image.Rect(col.Min.X, line.Min.Y, col.Min.X+l.Scale(labelEnd), line.Max.Y), label, colour, bitmapfont.Layout{Align: bitmapfont.AlignRight})
+	p.r.DrawTextIn(c, image.Rect(col.Min.X+l.Scale(timeStart), line.Min.Y, col.Max.X, line.Max.Y), t.In(p.r.location).Format("15:04"), colour, bitmapfont.Layout{})
+}
+
+// renderWeather draws the day's temperatures, with an icon for the weather to the
+// right. Animated icons play from the given time.
+func (r *ClockRenderer) renderWeather(c *image.RGBA, w weather.DayWeather, start time.Time) {
 	yOffset := 15
 	summaryStart := 36
 
-	r.addText(c, image.Point{X: 0, Y: yOffset}, fmt.Sprintf("%02.foC", w.TemperatureLow), colourTempLow)
-	r.addText(c, image.Point{X: 17, Y: yOffset}, fmt.Sprintf("%02.foC", w.TemperatureHigh), colourTempHigh)
+	// each piece of text is kept to its own column so they can't run into each other
+	l, t := r.Layout(c), r.FrameTheme()
+	top, bottom := l.Line(yOffset), l.Line(yOffset+7)
+	lowTemp := image.Rect(top.Min.X, top.Min.Y, l.X(17), top.Max.Y)
+	highTemp := image.Rect(l.X(17), top.Min.Y, l.X(summaryStart), top.Max.Y)
+	sky := image.Rect(bottom.Min.X, bottom.Min.Y, l.X(summaryStart), bottom.Max.Y)
+
+	layout := bitmapfont.Layout{}
+	r.DrawTextIn(c, lowTemp, fmt.Sprintf("%02.foC", w.TemperatureLow), t.TempLow, layout)
+	r.DrawTextIn(c, highTemp, fmt.Sprintf("%02.foC", w.TemperatureHigh), t.TempHigh, layout)
 
 	// Underneath temperatures, always shows
-	if w.Cloudy {
-		r.addText(c, image.Point{X: 0, Y: yOffset + 7}, "Cloudy", color.RGBA{179, 161, 136, 255})
-	} else {
-		r.addText(c, image.Point{X: 0, Y: yOffset + 7}, "Sunny", color.RGBA{255, 213, 0, 255})
-	}
+	condition := conditionFor(w)
+	r.DrawTextIn(c, sky, condition.label, t.Label, bitmapfont.Layout{Ellipsis: true})
 
-	// To the right, conditionally shows
-	if w.Snowy {
-		r.addText(c, image.Point{X: summaryStart, Y: yOffset}, "Snow", color.RGBA{255, 255, 255, 255})
-	} else if w.Rainy {
-		r.addText(c, image.Point{X: summaryStart, Y: yOffset}, "Rain", color.RGBA{0, 113, 237, 255})
+	// To the right, the icon, with a smaller one for wind beside it
+	elapsed := time.Since(start)
+	if icon, ok := r.weatherIcons.Get(condition.icon); ok {
+		icon.Draw(c, l.Pt(summaryStart+2, yOffset-1), elapsed)
 	}
 	if w.Windy {
-		r.addText(c, image.Point{X: summaryStart, Y: yOffset + 7}, "Windy", color.RGBA{0, 247, 255, 255})
+		if icon, ok := r.weatherIcons.Get("wind-small"); ok {
+			icon.Draw(c, l.Pt(summaryStart+16, yOffset+1), elapsed)
+		}
 	}
 
 	// Humidity, hidden for now
-	// r.addText(c, image.Point{X: summaryStart + 15, Y: yOffset}, fmt.Sprintf("H%02.f", (w.Humidity*100)), color.RGBA{230, 77, 0, 255})
+	// r.DrawText(c, image.Point{X: summaryStart + 15, Y: yOffset}, fmt.Sprintf("H%02.f", (w.Humidity*100)), color.RGBA{230, 77, 0, 255})
+}
+
+// weatherRefresh returns the refresh rate needed to animate the day's weather icon,
+// or zero if it is not animated.
+func (r *ClockRenderer) weatherRefresh(w weather.DayWeather) time.Duration {
+	if icon, ok := r.weatherIcons.Get(conditionFor(w).icon); ok {
+		return icon.FrameTime()
+	}
+	return 0
 }
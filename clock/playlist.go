@@ -0,0 +1,162 @@
+package clock
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/g-wilson/led/playlists"
+	"gopkg.in/yaml.v3"
+)
+
+// Playlist describes the pages the clock rotates through, in display order,
+// and the transition used between them.
+type Playlist struct {
+	Transition Transition      `yaml:"transition"`
+	Pages      []PlaylistEntry `yaml:"pages"`
+}
+
+// PlaylistEntry is a single page in the playlist. The ID selects the page,
+// and Options holds any page-specific settings, decoded by the page itself.
+// Duration overrides how long the page stays on screen, and Transition
+// overrides the style of transition used to bring it on screen.
+type PlaylistEntry struct {
+	ID         string          `yaml:"id"`
+	Enabled    *bool           `yaml:"enabled"`
+	Duration   time.Duration   `yaml:"duration"`
+	Transition TransitionStyle `yaml:"transition"`
+	Options    yaml.Node       `yaml:"options"`
+}
+
+// IsEnabled reports whether the entry should be shown. Entries are enabled unless
+// explicitly disabled.
+func (e PlaylistEntry) IsEnabled() bool {
+	return e.Enabled == nil || *e.Enabled
+}
+
+// DecodeOptions decodes the entry's options into v. It is a no-op when no
+// options were provided.
+func (e PlaylistEntry) DecodeOptions(v any) error {
+	if e.Options.Kind == 0 {
+		return nil
+	}
+	if err := e.Options.Decode(v); err != nil {
+		return fmt.Errorf("invalid options for page %q: %w", e.ID, err)
+	}
+	return nil
+}
+
+// LoadPlaylist reads a playlist from the YAML file at path. If path is empty,
+// the embedded default playlist is used.
+func LoadPlaylist(path string) (*Playlist, error) {
+	if path == "" {
+		return parsePlaylist(playlists.DefaultYAML)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("playlist: cannot read %q: %w", path, err)
+	}
+
+	p, err := parsePlaylist(data)
+	if err != nil {
+		return nil, fmt.Errorf("playlist: %q: %w", path, err)
+	}
+
+	return p, nil
+}
+
+func parsePlaylist(data []byte) (*Playlist, error) {
+	p := &Playlist{}
+	if err := yaml.Unmarshal(data, p); err != nil {
+		return nil, fmt.Errorf("invalid YAML: %w", err)
+	}
+
+	if len(p.Pages) == 0 {
+		return nil, fmt.Errorf("no pages defined")
+	}
+
+	if err := p.Transition.Style.validate(); err != nil {
+		return nil, err
+	}
+	if p.Transition.Duration < 0 {
+		return nil, fmt.Errorf("transition duration cannot be negative")
+	}
+	if p.Transition.Duration == 0 {
+		p.Transition.Duration = defaultTransitionDuration
+	}
+
+	for i, entry := range p.Pages {
+		if entry.ID == "" {
+			return nil, fmt.Errorf("page %d has no id", i+1)
+		}
+		if _, ok := lookupPage(entry.ID); !ok {
+			return nil, fmt.Errorf("page %d: unknown page id %q (known ids: %s)", i+1, entry.ID, strings.Join(RegisteredPages(), ", "))
+		}
+		if entry.Duration < 0 {
+			return nil, fmt.Errorf("page %d: duration cannot be negative", i+1)
+		}
+		if err := entry.Transition.validate(); err != nil {
+			return nil, fmt.Errorf("page %d: %w", i+1, err)
+		}
+	}
+
+	return p, nil
+}
+
+// buildPages turns the playlist into the ordered list of pages to rotate through,
+// initialising each page as it is created.
+func (r *ClockRenderer) buildPages(ctx context.Context, p *Playlist) ([]rotationPage, error) {
+	var pages []rotationPage
+	for _, entry := range p.Pages {
+		if !entry.IsEnabled() {
+			continue
+		}
+
+		factory, _ := lookupPage(entry.ID)
+		built, err := factory(r, entry)
+		if err != nil {
+			return nil, fmt.Errorf("playlist: %w", err)
+		}
+
+		for _, pg := range built {
+			if err := pg.Init(ctx); err != nil {
+				log.Printf("playlist: page %q failed to initialise, skipping: %v", pg.Info().Name, err)
+				continue
+			}
+
+			// the playlist duration takes precedence over the page's own preference,
+			// falling back to the default page interval if neither is set
+			duration := entry.Duration
+			if duration == 0 {
+				duration = pg.Info().Duration
+			}
+			if duration == 0 {
+				duration = r.pageInterval
+			}
+
+			transition := p.Transition
+			if entry.Transition != "" {
+				transition.Style = entry.Transition
+			}
+
+			info := pg.Info()
+			pages = append(pages, rotationPage{
+				Page:       pg,
+				id:         info.ID,
+				name:       info.Name,
+				duration:   duration,
+				transition: transition,
+			})
+		}
+	}
+
+	if len(pages) == 0 {
+		return nil, fmt.Errorf("playlist: no pages available to show")
+	}
+
+	return pages, nil
+}
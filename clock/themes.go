@@ -0,0 +1,57 @@
+package clock
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/g-wilson/led/config"
+	"github.com/g-wilson/led/internal/theme"
+)
+
+// themeSettings are the themes the clock can switch between, and which is in use.
+type themeSettings struct {
+	set      *theme.Set
+	day      *theme.Theme                // THEME
+	night    *theme.Theme                // NIGHT_THEME, or nil to keep the day theme overnight
+	override atomic.Pointer[theme.Theme] // chosen at runtime, in place of both
+}
+
+func loadThemes(cfg *config.Settings) (*themeSettings, error) {
+	set, err := theme.Load(cfg.ThemeFiles)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &themeSettings{set: set}
+	var ok bool
+	if t.day, ok = set.Get(cfg.Theme); !ok {
+		return nil, fmt.Errorf("invalid THEME: unknown theme %q", cfg.Theme)
+	}
+	if cfg.NightTheme != "" {
+		if t.night, ok = set.Get(cfg.NightTheme); !ok {
+			return nil, fmt.Errorf("invalid NIGHT_THEME: unknown theme %q", cfg.NightTheme)
+		}
+	}
+
+	return t, nil
+}
+
+// currentTheme returns the theme to draw in at the given time: the one chosen at
+// runtime if there is one, otherwise the night theme during the night window, or
+// the day theme.
+func (r *ClockRenderer) currentTheme(now time.Time) *theme.Theme {
+	if t := r.themes.override.Load(); t != nil {
+		return t
+	}
+	if r.themes.night != nil && r.inNightWindow(now) {
+		return r.themes.night
+	}
+	return r.themes.day
+}
+
+// FrameTheme returns the theme of the frame being drawn, which pages take their
+// colours from. It is only meaningful while drawing.
+func (r *ClockRenderer) FrameTheme() *theme.Theme {
+	return r.frameTheme
+}
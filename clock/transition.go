@@ -0,0 +1,116 @@
+package clock
+
+import (
+	"fmt"
+	"image"
+	"time"
+
+	"golang.org/x/image/draw"
+)
+
+// TransitionStyle is the animation used when one page replaces another.
+type TransitionStyle string
+
+const (
+	TransitionNone  TransitionStyle = "none"
+	TransitionSlide TransitionStyle = "slide"
+	TransitionWipe  TransitionStyle = "wipe"
+	TransitionFade  TransitionStyle = "fade"
+)
+
+const defaultTransitionDuration = 400 * time.Millisecond
+
+// Transition configures the animation between pages.
+type Transition struct {
+	Style    TransitionStyle `yaml:"style"`
+	Duration time.Duration   `yaml:"duration"`
+}
+
+func (s TransitionStyle) validate() error {
+	switch s {
+	case "", TransitionNone, TransitionSlide, TransitionWipe, TransitionFade:
+		return nil
+	default:
+		return fmt.Errorf("unknown transition %q (expected none, slide, wipe or fade)", s)
+	}
+}
+
+// animated reports whether the transition has anything to animate.
+func (t Transition) animated() bool {
+	return t.Style != "" && t.Style != TransitionNone && t.Duration > 0
+}
+
+// transitionState tracks an in-progress transition. It is owned by the rendering goroutine.
+type transitionState struct {
+	active     bool
+	from       int // page index being transitioned away from
+	transition Transition
+	startedAt  time.Time
+
+	// offscreen buffers for the outgoing and incoming pages, allocated on first use
+	fromBuf *image.RGBA
+	toBuf   *image.RGBA
+}
+
+// progress returns how far through the transition we are, from 0 to 1.
+func (t *transitionState) progress(now time.Time) float64 {
+	p := float64(now.Sub(t.startedAt)) / float64(t.transition.Duration)
+	if p < 0 {
+		return 0
+	}
+	if p > 1 {
+		return 1
+	}
+	return p
+}
+
+// buffers returns the offscreen buffers, cleared and sized to match bounds.
+func (t *transitionState) buffers(bounds image.Rectangle) (from, to *image.RGBA) {
+	if t.fromBuf == nil || t.fromBuf.Bounds() != bounds {
+		t.fromBuf = image.NewRGBA(bounds)
+		t.toBuf = image.NewRGBA(bounds)
+	} else {
+		clear(t.fromBuf.Pix)
+		clear(t.toBuf.Pix)
+	}
+	return t.fromBuf, t.toBuf
+}
+
+// easeInOut applies a cubic ease so transitions start and finish gently.
+func easeInOut(p float64) float64 {
+	if p < 0.5 {
+		return 4 * p * p * p
+	}
+	f := 2*p - 2
+	return 0.5*f*f*f + 1
+}
+
+// composeTransition draws the blend of the outgoing and incoming frames into dst.
+func composeTransition(dst, from, to *image.RGBA, style TransitionStyle, progress float64) {
+	b := dst.Bounds()
+	p := easeInOut(progress)
+
+	switch style {
+	case TransitionSlide:
+		// outgoing page moves off to the left as the incoming page follows it in
+		offset := int(p*float64(b.Dx()) + 0.5)
+		draw.Draw(dst, b, from, b.Min.Add(image.Pt(offset, 0)), draw.Src)
+		draw.Draw(dst, b.Add(image.Pt(b.Dx()-offset, 0)).Intersect(b), to, b.Min, draw.Src)
+
+	case TransitionWipe:
+		// incoming page is revealed from the left edge
+		edge := b.Min.X + int(p*float64(b.Dx())+0.5)
+		draw.Draw(dst, b, from, b.Min, draw.Src)
+		draw.Draw(dst, image.Rect(b.Min.X, b.Min.Y, edge, b.Max.Y), to, b.Min, draw.Src)
+
+	case TransitionFade:
+		// crossfade, blending each channel linearly
+		w := uint32(p * 256)
+		for i := range dst.Pix {
+			dst.Pix[i] = uint8((uint32(from.Pix[i])*(256-w) + uint32(to.Pix[i])*w) >> 8)
+		}
+
+	default:
+		draw.Draw(dst, b, to, b.Min, draw.Src)
+	}
+}
@@ -0,0 +1,80 @@
+package clock
+
+import (
+	"embed"
+	"fmt"
+
+	"github.com/g-wilson/led/internal/sprite"
+	"github.com/g-wilson/led/internal/weather"
+)
+
+// The weather sprite sheet has a 12x12 icon for each condition, and an 8x8 version
+// of it named with a "-small" suffix.
+//
+//go:embed sprites/weather.png sprites/weather.json
+var spriteFiles embed.FS
+
+func loadWeatherIcons() (*sprite.Sheet, error) {
+	img, err := spriteFiles.ReadFile("sprites/weather.png")
+	if err != nil {
+		return nil, fmt.Errorf("error reading weather icons: %w", err)
+	}
+	index, err := spriteFiles.ReadFile("sprites/weather.json")
+	if err != nil {
+		return nil, fmt.Errorf("error reading weather icons: %w", err)
+	}
+	return sprite.Load(img, index)
+}
+
+// weatherCondition is how a day's weather is shown: an icon from the weather
+// sprite sheet, and a label short enough to fit under the temperatures.
+type weatherCondition struct {
+	icon  string
+	label string
+}
+
+// weatherConditions maps Tomorrow.io weather codes to conditions.
+// See https://docs.tomorrow.io/reference/data-layers-weather-codes
+var weatherConditions = map[int]weatherCondition{
+	1000: {"clear", "Clear"},
+	1100: {"mostly-clear", "Fair"},
+	1101: {"partly-cloudy", "Pt cloudy"},
+	1102: {"mostly-cloudy", "Cloudy"},
+	1001: {"cloudy", "Overcast"},
+	2000: {"fog", "Fog"},
+	2100: {"fog", "Mist"},
+	4000: {"drizzle", "Drizzle"},
+	4001: {"rain", "Rain"},
+	4200: {"drizzle", "Lt rain"},
+	4201: {"heavy-rain", "Hvy rain"},
+	5000: {"snow", "Snow"},
+	5001: {"snow", "Flurries"},
+	5100: {"snow", "Lt snow"},
+	5101: {"snow", "Hvy snow"},
+	6000: {"sleet", "Frz drizl"},
+	6001: {"sleet", "Frz rain"},
+	6200: {"sleet", "Frz rain"},
+	6201: {"sleet", "Frz rain"},
+	7000: {"sleet", "Ice"},
+	7101: {"sleet", "Ice"},
+	7102: {"sleet", "Ice"},
+	8000: {"thunderstorm", "Storm"},
+}
+
+// conditionFor returns the condition for the day's weather code, falling back to
+// its rain, snow and cloud flags for providers without weather codes.
+func conditionFor(w weather.DayWeather) weatherCondition {
+	if c, ok := weatherConditions[w.WeatherCode]; ok {
+		return c
+	}
+	switch {
+	case w.Snowy:
+		return weatherCondition{"snow", "Snow"}
+	case w.Rainy:
+		return weatherCondition{"rain", "Rain"}
+	case w.Cloudy:
+		return weatherCondition{"cloudy", "Cloudy"}
+	default:
+		return weatherCondition{"clear", "Sunny"}
+	}
+}
@@ -2,7 +2,6 @@ package main
 
 import (
 	"context"
-	"image"
 	"log"
 	"os/signal"
 	"runtime"
@@ -11,6 +10,8 @@ import (
 	"github.com/g-wilson/led/clock"
 	"github.com/g-wilson/led/config"
 	"github.com/g-wilson/led/internal/framestreamer"
+	"github.com/g-wilson/led/internal/pixelmap"
+	"github.com/g-wilson/led/internal/remote"
 	"github.com/g-wilson/led/internal/windowrenderer"
 
 	"github.com/go-gl/glfw/v3.3/glfw"
@@ -41,23 +42,50 @@ func main() {
 		log.Fatalln(err)
 	}
 
+	if err := remote.Start(ctx, cfg, clockApp); err != nil {
+		log.Fatalln(err)
+	}
+
+	// The window shows the canvas as the pages draw it, the size of the panels as mounted
+	mapper, err := pixelmap.New(pixelmap.Options{
+		PanelWidth:  cfg.LEDCols,
+		PanelHeight: cfg.LEDRows,
+		Chain:       cfg.LEDChain,
+		Parallel:    cfg.LEDParallel,
+		Layout:      cfg.LEDLayout,
+		Rows:        cfg.LEDLayoutRows,
+		Rotate:      cfg.LEDRotate,
+		MirrorH:     cfg.LEDMirrorH,
+		MirrorV:     cfg.LEDMirrorV,
+	})
+	if err != nil {
+		log.Fatalln(err)
+	}
+	bounds := mapper.Bounds()
+
 	// Create framestreamer
 	fs := framestreamer.New(framestreamer.Params{
-		Bounds: image.Rectangle{
-			Min: image.Point{X: 0, Y: 0},
-			Max: image.Point{X: cfg.LEDCols, Y: cfg.LEDRows},
-		},
+		Bounds:      bounds,
 		FrametimeMs: framestreamer.OneFPS,
 		Renderer:    clockApp,
+		Filters:     clockApp.Filters(),
+		RepeatMs:    clockApp.RepeatMs(),
 	})
 
 	// Create window renderer with direct channel access to framestreamer
-	renderer, err := windowrenderer.New("LED Matrix Debug", cfg.LEDRows, cfg.LEDCols, fs.C, fs.E)
+	renderer, err := windowrenderer.New(windowTitle(true), bounds.Dy(), bounds.Dx(), fs.C, fs.E)
 	if err != nil {
 		log.Fatalln("failed to create window renderer:", err)
 	}
 	defer renderer.Cleanup()
 
+	// C toggles the colour calibration, to compare the calibrated frames with the raw ones
+	calibration := clockApp.Calibration()
+	renderer.OnKey(glfw.KeyC, func() {
+		calibration.SetEnabled(!calibration.Enabled())
+		renderer.SetTitle(windowTitle(calibration.Enabled()))
+	})
+
 	// Start framestreamer - calls the clock app to render frames at the given framerate
 	go fs.Start()
 	defer fs.Stop()
@@ -68,3 +96,10 @@ func main() {
 		log.Fatalln("renderer error:", err)
 	}
 }
+
+func windowTitle(calibrated bool) string {
+	if calibrated {
+		return "LED Matrix Debug (calibrated, C to toggle)"
+	}
+	return "LED Matrix Debug (raw, C to toggle)"
+}
@@ -12,6 +12,8 @@ import (
 	"github.com/g-wilson/led/clock"
 	"github.com/g-wilson/led/config"
 	"github.com/g-wilson/led/internal/framestreamer"
+	"github.com/g-wilson/led/internal/pixelmap"
+	"github.com/g-wilson/led/internal/remote"
 )
 
 func main() {
@@ -20,10 +22,22 @@ func main() {
 		log.Fatalln(err)
 	}
 
-	bounds := image.Rectangle{
-		Min: image.Point{X: 0, Y: 0},
-		Max: image.Point{X: cfg.LEDCols, Y: cfg.LEDRows},
+	// the PNG shows the canvas as the pages draw it, the size of the panels as mounted
+	mapper, err := pixelmap.New(pixelmap.Options{
+		PanelWidth:  cfg.LEDCols,
+		PanelHeight: cfg.LEDRows,
+		Chain:       cfg.LEDChain,
+		Parallel:    cfg.LEDParallel,
+		Layout:      cfg.LEDLayout,
+		Rows:        cfg.LEDLayoutRows,
+		Rotate:      cfg.LEDRotate,
+		MirrorH:     cfg.LEDMirrorH,
+		MirrorV:     cfg.LEDMirrorV,
+	})
+	if err != nil {
+		log.Fatalln(err)
 	}
+	bounds := mapper.Bounds()
 
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
@@ -33,10 +47,15 @@ func main() {
 		log.Fatalln(err)
 	}
 
+	if err := remote.Start(ctx, cfg, clockApp); err != nil {
+		log.Fatalln(err)
+	}
+
 	fs := framestreamer.New(framestreamer.Params{
 		Bounds:      bounds,
 		FrametimeMs: framestreamer.OneFPS,
 		Renderer:    clockApp,
+		Filters:     clockApp.Filters(),
 	})
 
 	go func() {
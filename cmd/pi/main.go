@@ -12,6 +12,8 @@ import (
 	"github.com/g-wilson/led/clock"
 	"github.com/g-wilson/led/config"
 	"github.com/g-wilson/led/internal/framestreamer"
+	"github.com/g-wilson/led/internal/pixelmap"
+	"github.com/g-wilson/led/internal/remote"
 
 	rgbmatrix "github.com/mcuadros/go-rpi-rgb-led-matrix"
 )
@@ -22,9 +24,26 @@ func main() {
 		log.Fatalln(err)
 	}
 
+	mapper, err := pixelmap.New(pixelmap.Options{
+		PanelWidth:  cfg.LEDCols,
+		PanelHeight: cfg.LEDRows,
+		Chain:       cfg.LEDChain,
+		Parallel:    cfg.LEDParallel,
+		Layout:      cfg.LEDLayout,
+		Rows:        cfg.LEDLayoutRows,
+		Rotate:      cfg.LEDRotate,
+		MirrorH:     cfg.LEDMirrorH,
+		MirrorV:     cfg.LEDMirrorV,
+	})
+	if err != nil {
+		log.Fatalln(err)
+	}
+
 	matrixConfig := &rgbmatrix.DefaultConfig
 	matrixConfig.Rows = cfg.LEDRows
 	matrixConfig.Cols = cfg.LEDCols
+	matrixConfig.ChainLength = cfg.LEDChain
+	matrixConfig.Parallel = cfg.LEDParallel
 	matrixConfig.PWMBits = cfg.LEDPWMBits
 	matrixConfig.PWMLSBNanoseconds = cfg.LEDPWMLSBNano
 	matrixConfig.Brightness = cfg.LEDBrightness
@@ -46,10 +65,19 @@ func main() {
 		log.Fatalln(err)
 	}
 
+	if err := remote.Start(ctx, cfg, clockApp); err != nil {
+		log.Fatalln(err)
+	}
+
+	// frames are drawn on the canvas as mounted, then mapped to the panels' pixels
+	physical := image.NewRGBA(mapper.PhysicalBounds())
+
 	fs := framestreamer.New(framestreamer.Params{
-		Bounds:      c.Bounds(),
+		Bounds:      mapper.Bounds(),
 		FrametimeMs: framestreamer.OneFPS,
 		Renderer:    clockApp,
+		Filters:     clockApp.Filters(),
+		RepeatMs:    clockApp.RepeatMs(),
 	})
 
 	go func() {
@@ -66,7 +94,8 @@ func main() {
 				if !ok {
 					return
 				}
-				draw.Draw(c, c.Bounds(), frame, image.Point{}, draw.Src)
+				mapper.Map(physical, frame)
+				draw.Draw(c, c.Bounds(), physical, image.Point{}, draw.Src)
 				c.Render()
 			}
 		}
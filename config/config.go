@@ -16,6 +16,60 @@ type Settings struct {
 	Timezone string `env:"TIMEZONE" envDefault:"Europe/London"`
 	Debug    bool   `env:"DEBUG"    envDefault:"false"`
 
+	// Night mode
+	NightMode         string   `env:"NIGHT_MODE"          envDefault:"blank"`
+	NightStart        string   `env:"NIGHT_START"         envDefault:"20:00"`
+	NightEnd          string   `env:"NIGHT_END"           envDefault:"06:00"`
+	NightWeekendStart string   `env:"NIGHT_WEEKEND_START"`
+	NightWeekendEnd   string   `env:"NIGHT_WEEKEND_END"`
+	NightWeekendDays  []string `env:"NIGHT_WEEKEND_DAYS"  envSeparator:"," envDefault:"fri,sat"`
+	NightPages        []string `env:"NIGHT_PAGES"         envSeparator:","`
+
+	// Brightness (optional — the curve is disabled if not set)
+	BrightnessCurve string `env:"BRIGHTNESS_CURVE"`
+	BrightnessMin   int    `env:"BRIGHTNESS_MIN"   envDefault:"5"`
+	BrightnessMax   int    `env:"BRIGHTNESS_MAX"   envDefault:"100"`
+
+	// Colour calibration (optional) — gamma and gains take one value, or one each for red, green and blue
+	CalibrationGamma []float64 `env:"CALIBRATION_GAMMA" envSeparator:","`
+	CalibrationGains []float64 `env:"CALIBRATION_GAINS" envSeparator:","`
+	CalibrationFloor int       `env:"CALIBRATION_FLOOR"`
+	// Dithering (optional) — smooths dim colours by sending frames at DITHER_FPS
+	Dither    bool `env:"DITHER"     envDefault:"false"`
+	DitherFPS int  `env:"DITHER_FPS" envDefault:"60"`
+
+	// HTTP API (optional — disabled if not set)
+	HTTPAddr string `env:"HTTP_ADDR"`
+
+	// MQTT (optional — disabled if no broker is set)
+	MQTTBroker          string `env:"MQTT_BROKER"`
+	MQTTUsername        string `env:"MQTT_USERNAME"`
+	MQTTPassword        string `env:"MQTT_PASSWORD"`
+	MQTTClientID        string `env:"MQTT_CLIENT_ID"         envDefault:"led-clock"`
+	MQTTTopicPrefix     string `env:"MQTT_TOPIC_PREFIX"`
+	MQTTMessageTopic    string `env:"MQTT_MESSAGE_TOPIC"`
+	MQTTPageTopic       string `env:"MQTT_PAGE_TOPIC"`
+	MQTTBrightnessTopic string `env:"MQTT_BRIGHTNESS_TOPIC"`
+	MQTTThemeTopic      string `env:"MQTT_THEME_TOPIC"`
+	MQTTDiscovery       bool   `env:"MQTT_DISCOVERY"         envDefault:"true"`
+	MQTTDiscoveryPrefix string `env:"MQTT_DISCOVERY_PREFIX"  envDefault:"homeassistant"`
+
+	// Fonts (optional) — extra BDF fonts, named after their files
+	FontFiles []string `env:"FONT_FILES" envSeparator:","`
+	FontDir   string   `env:"FONT_DIR"`
+	// FontFallbacks are tried in order for characters a font doesn't have
+	FontFallbacks []string `env:"FONT_FALLBACKS" envSeparator:"," envDefault:"tom-thumb-accents,7x13"`
+	// BodyFont is the text font for panels with room for it
+	BodyFont string `env:"BODY_FONT" envDefault:"5x7"`
+
+	// Themes — THEME by day, NIGHT_THEME (optional) in the night window
+	Theme      string   `env:"THEME"       envDefault:"default"`
+	NightTheme string   `env:"NIGHT_THEME"`
+	ThemeFiles []string `env:"THEME_FILES" envSeparator:","`
+
+	// Playlist
+	PlaylistFile string `env:"PLAYLIST_FILE"`
+
 	// Calendar
 	CalendarFiles []string `env:"CALENDAR_FILES" envSeparator:","`
 
@@ -27,6 +81,15 @@ type Settings struct {
 	LEDBrightness int    `env:"LED_BRIGHTNESS" envDefault:"30"`
 	LEDHardware   string `env:"LED_HARDWARE"   envDefault:"adafruit-hat"`
 
+	// LED panel mounting — LED_ROWS and LED_COLS are the size of one panel
+	LEDChain      int    `env:"LED_CHAIN"       envDefault:"1"`
+	LEDParallel   int    `env:"LED_PARALLEL"    envDefault:"1"`
+	LEDLayout     string `env:"LED_LAYOUT"      envDefault:"chain"`
+	LEDLayoutRows int    `env:"LED_LAYOUT_ROWS" envDefault:"2"`
+	LEDRotate     int    `env:"LED_ROTATE"      envDefault:"0"`
+	LEDMirrorH    bool   `env:"LED_MIRROR_H"    envDefault:"false"`
+	LEDMirrorV    bool   `env:"LED_MIRROR_V"    envDefault:"false"`
+
 	// Air Matters (optional — skipped if API key not set)
 	AirMattersAPIKey string `env:"AIRMATTERS_API_KEY"`
 	AirMattersRefresh int   `env:"AIRMATTERS_REFRESH" envDefault:"7200"`
@@ -4,8 +4,11 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"strconv"
 	"sync"
 	"time"
+
+	"github.com/g-wilson/led/internal/history"
 )
 
 const fetchTimeout = 15 * time.Second
@@ -17,6 +20,7 @@ type Agent struct {
 
 	mu   sync.RWMutex
 	data AirCondition
+	aqi  *history.Series
 }
 
 type AgentOptions struct {
@@ -30,6 +34,7 @@ func NewAgent(ctx context.Context, client *Client, options AgentOptions) (*Agent
 		ctx:     ctx,
 		client:  client,
 		options: options,
+		aqi:     history.New(history.Capacity(time.Duration(options.Refresh) * time.Second)),
 	}
 
 	if err := a.populateCache(); err != nil {
@@ -69,6 +74,9 @@ func (a *Agent) populateCache() error {
 	defer a.mu.Unlock()
 
 	a.data = data
+	if aqi, err := strconv.ParseFloat(data.AQI.Value, 64); err == nil {
+		a.aqi.Add(time.Now(), aqi)
+	}
 
 	return nil
 }
@@ -79,3 +87,8 @@ func (a *Agent) Get() AirCondition {
 
 	return a.data
 }
+
+// GetAQIHistory returns the AQI readings fetched so far.
+func (a *Agent) GetAQIHistory() *history.Series {
+	return a.aqi
+}
@@ -0,0 +1,162 @@
+// Package animation decodes animated GIF and APNG images into whole frames ready to
+// draw, each shown for its own delay. Other images decode to a single still frame.
+package animation
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/draw"
+	"io"
+	"time"
+)
+
+// defaultDelay is used for frames with no delay, or one too short to be meant, as
+// browsers do.
+const defaultDelay = 100 * time.Millisecond
+
+// minDelay is the shortest frame delay taken as meant.
+const minDelay = 20 * time.Millisecond
+
+// maxPixels is the largest image decoded, far bigger than any panel, so a corrupt
+// or hostile header can't have a canvas allocated for it.
+const maxPixels = 1024 * 1024
+
+// Animation is a sequence of frames, all the size of the image.
+type Animation struct {
+	Frames []*image.RGBA
+	Delays []time.Duration
+	// Loops is how many times the animation plays before stopping on its last
+	// frame, or zero to loop forever.
+	Loops int
+
+	total time.Duration
+}
+
+// Decode reads a GIF, PNG or APNG image. Animated GIFs and APNGs have a frame for
+// each of theirs, composited as they would be displayed.
+func Decode(r io.Reader) (*Animation, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case bytes.HasPrefix(data, []byte("GIF8")):
+		return decodeGIF(data)
+	case isAPNG(data):
+		return decodeAPNG(data)
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSize(uint64(cfg.Width), uint64(cfg.Height)); err != nil {
+		return nil, err
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	return Still(img), nil
+}
+
+// checkSize checks an image's size, as its header gives it, is one worth decoding.
+func checkSize(width, height uint64) error {
+	if width == 0 || height == 0 {
+		return fmt.Errorf("invalid image size %dx%d", width, height)
+	}
+	if width*height > maxPixels {
+		return fmt.Errorf("image too big at %dx%d: expected at most %d pixels", width, height, maxPixels)
+	}
+	return nil
+}
+
+// Still creates an animation of a single frame.
+func Still(img image.Image) *Animation {
+	a := &Animation{}
+	a.add(toRGBA(img), 0)
+	return a
+}
+
+// Bounds is the size of the animation's frames, at the origin.
+func (a *Animation) Bounds() image.Rectangle {
+	return a.Frames[0].Bounds()
+}
+
+// Animated reports whether there is more than one frame.
+func (a *Animation) Animated() bool {
+	return len(a.Frames) > 1
+}
+
+// Frame returns the frame to show once the animation has been playing for elapsed.
+func (a *Animation) Frame(elapsed time.Duration) *image.RGBA {
+	i, _ := a.position(elapsed)
+	return a.Frames[i]
+}
+
+// NextFrame returns how long after elapsed the frame shown changes, or zero if it
+// never will.
+func (a *Animation) NextFrame(elapsed time.Duration) time.Duration {
+	_, remaining := a.position(elapsed)
+	return remaining
+}
+
+// position returns the index of the frame shown at elapsed, and how long it has left.
+func (a *Animation) position(elapsed time.Duration) (int, time.Duration) {
+	last := len(a.Frames) - 1
+	if !a.Animated() {
+		return 0, 0
+	}
+	if elapsed < 0 {
+		return 0, a.Delays[0] - elapsed
+	}
+	if a.Loops > 0 && elapsed >= a.total*time.Duration(a.Loops) {
+		return last, 0
+	}
+
+	t := elapsed % a.total
+	for i, d := range a.Delays {
+		if t < d {
+			return i, d - t
+		}
+		t -= d
+	}
+	return last, 0
+}
+
+func (a *Animation) add(frame *image.RGBA, delay time.Duration) {
+	if delay < minDelay {
+		delay = defaultDelay
+	}
+	a.Frames = append(a.Frames, frame)
+	a.Delays = append(a.Delays, delay)
+	a.total += delay
+}
+
+// validate checks the animation has at least one frame, all of the same size.
+func (a *Animation) validate() error {
+	if len(a.Frames) == 0 {
+		return fmt.Errorf("image has no frames")
+	}
+	for i, f := range a.Frames[1:] {
+		if f.Bounds() != a.Frames[0].Bounds() {
+			return fmt.Errorf("frame %d is a different size", i+1)
+		}
+	}
+	return nil
+}
+
+func toRGBA(img image.Image) *image.RGBA {
+	b := img.Bounds()
+	out := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(out, out.Bounds(), img, b.Min, draw.Src)
+	return out
+}
+
+func clone(img *image.RGBA) *image.RGBA {
+	out := image.NewRGBA(img.Bounds())
+	copy(out.Pix, img.Pix)
+	return out
+}
@@ -0,0 +1,241 @@
+package animation
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/draw"
+	"image/png"
+	"time"
+)
+
+// APNG extends PNG with chunks image/png skips over: acTL says how many frames and
+// plays there are, an fcTL before each frame gives its size, position, delay and
+// how it is composited, and fdAT chunks hold the image data of frames after the
+// first. Each frame is decoded by rebuilding it as a PNG of its own.
+// See https://wiki.mozilla.org/APNG_Specification
+
+const pngSignature = "\x89PNG\r\n\x1a\n"
+
+// fcTL dispose and blend operations.
+const (
+	apngDisposeNone       = 0
+	apngDisposeBackground = 1
+	apngDisposePrevious   = 2
+
+	apngBlendSource = 0
+)
+
+type pngChunk struct {
+	typ  string
+	data []byte
+}
+
+// apngFrame is a frame's control chunk and image data.
+type apngFrame struct {
+	width, height uint32
+	x, y          uint32
+	delay         time.Duration
+	dispose       byte
+	blend         byte
+	data          []byte
+}
+
+// isAPNG reports whether data is a PNG with an animation control chunk.
+func isAPNG(data []byte) bool {
+	if !bytes.HasPrefix(data, []byte(pngSignature)) {
+		return false
+	}
+	chunks, err := readChunks(data)
+	if err != nil {
+		return false
+	}
+	for _, c := range chunks {
+		switch c.typ {
+		case "acTL":
+			return true
+		case "IDAT":
+			// acTL must come before the image data
+			return false
+		}
+	}
+	return false
+}
+
+func decodeAPNG(data []byte) (*Animation, error) {
+	chunks, err := readChunks(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		header   []byte     // IHDR
+		shared   []pngChunk // chunks before the image data, such as the palette, which every frame needs
+		frames   []*apngFrame
+		current  *apngFrame
+		seenIDAT bool
+		plays    uint32
+	)
+	for _, c := range chunks {
+		switch c.typ {
+		case "IHDR":
+			header = c.data
+		case "acTL":
+			if len(c.data) != 8 {
+				return nil, fmt.Errorf("apng: invalid acTL chunk")
+			}
+			plays = binary.BigEndian.Uint32(c.data[4:])
+		case "fcTL":
+			f, err := parseFrameControl(c.data)
+			if err != nil {
+				return nil, err
+			}
+			frames = append(frames, f)
+			current = f
+		case "IDAT":
+			seenIDAT = true
+			// without an fcTL first, the default image is not part of the animation
+			if current != nil {
+				current.data = append(current.data, c.data...)
+			}
+		case "fdAT":
+			if current == nil || len(c.data) < 4 {
+				return nil, fmt.Errorf("apng: unexpected fdAT chunk")
+			}
+			current.data = append(current.data, c.data[4:]...)
+		case "IEND":
+		default:
+			if !seenIDAT {
+				shared = append(shared, c)
+			}
+		}
+	}
+	if len(header) != 13 {
+		return nil, fmt.Errorf("apng: invalid IHDR chunk")
+	}
+
+	a := &Animation{Loops: int(plays)}
+	width, height := binary.BigEndian.Uint32(header[0:]), binary.BigEndian.Uint32(header[4:])
+	if err := checkSize(uint64(width), uint64(height)); err != nil {
+		return nil, fmt.Errorf("apng: %w", err)
+	}
+	canvas := image.NewRGBA(image.Rect(0, 0, int(width), int(height)))
+
+	for i, f := range frames {
+		// compared by subtracting, as adding the offsets and sizes can overflow
+		if f.x > width || f.width > width-f.x || f.y > height || f.height > height-f.y {
+			return nil, fmt.Errorf("apng: frame %d lies outside the image", i)
+		}
+		r := image.Rect(int(f.x), int(f.y), int(f.x)+int(f.width), int(f.y)+int(f.height))
+
+		img, err := png.Decode(bytes.NewReader(framePNG(header, shared, f)))
+		if err != nil {
+			return nil, fmt.Errorf("apng: frame %d: %w", i, err)
+		}
+
+		dispose := f.dispose
+		if dispose == apngDisposePrevious && i == 0 {
+			dispose = apngDisposeBackground
+		}
+		var previous *image.RGBA
+		if dispose == apngDisposePrevious {
+			previous = clone(canvas)
+		}
+
+		op := draw.Over
+		if f.blend == apngBlendSource {
+			op = draw.Src
+		}
+		draw.Draw(canvas, r, img, img.Bounds().Min, op)
+		a.add(clone(canvas), f.delay)
+
+		switch dispose {
+		case apngDisposeBackground:
+			draw.Draw(canvas, r, image.Transparent, image.Point{}, draw.Src)
+		case apngDisposePrevious:
+			canvas = previous
+		}
+	}
+
+	if err := a.validate(); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func parseFrameControl(data []byte) (*apngFrame, error) {
+	if len(data) != 26 {
+		return nil, fmt.Errorf("apng: invalid fcTL chunk")
+	}
+	be := binary.BigEndian
+	f := &apngFrame{
+		width:   be.Uint32(data[4:]),
+		height:  be.Uint32(data[8:]),
+		x:       be.Uint32(data[12:]),
+		y:       be.Uint32(data[16:]),
+		dispose: data[24],
+		blend:   data[25],
+	}
+	if f.dispose > apngDisposePrevious {
+		return nil, fmt.Errorf("apng: invalid dispose op %d", f.dispose)
+	}
+
+	// the delay is a fraction of a second, where a denominator of zero means 100
+	num, den := be.Uint16(data[20:]), be.Uint16(data[22:])
+	if den == 0 {
+		den = 100
+	}
+	f.delay = time.Duration(num) * time.Second / time.Duration(den)
+
+	return f, nil
+}
+
+// framePNG builds a PNG holding just the frame's image.
+func framePNG(header []byte, shared []pngChunk, f *apngFrame) []byte {
+	ihdr := append([]byte(nil), header...)
+	binary.BigEndian.PutUint32(ihdr[0:], f.width)
+	binary.BigEndian.PutUint32(ihdr[4:], f.height)
+
+	var buf bytes.Buffer
+	buf.WriteString(pngSignature)
+	writeChunk(&buf, "IHDR", ihdr)
+	for _, c := range shared {
+		writeChunk(&buf, c.typ, c.data)
+	}
+	writeChunk(&buf, "IDAT", f.data)
+	writeChunk(&buf, "IEND", nil)
+	return buf.Bytes()
+}
+
+func readChunks(data []byte) ([]pngChunk, error) {
+	var chunks []pngChunk
+	data = data[len(pngSignature):]
+	for len(data) > 0 {
+		if len(data) < 12 {
+			return nil, fmt.Errorf("png: truncated chunk")
+		}
+		n := binary.BigEndian.Uint32(data)
+		if uint64(n)+12 > uint64(len(data)) {
+			return nil, fmt.Errorf("png: truncated chunk")
+		}
+		chunks = append(chunks, pngChunk{typ: string(data[4:8]), data: data[8 : 8+n]})
+		data = data[12+n:]
+	}
+	return chunks, nil
+}
+
+func writeChunk(buf *bytes.Buffer, typ string, data []byte) {
+	var n [4]byte
+	binary.BigEndian.PutUint32(n[:], uint32(len(data)))
+	buf.Write(n[:])
+	buf.WriteString(typ)
+	buf.Write(data)
+
+	crc := crc32.NewIEEE()
+	crc.Write([]byte(typ))
+	crc.Write(data)
+	binary.BigEndian.PutUint32(n[:], crc.Sum32())
+	buf.Write(n[:])
+}
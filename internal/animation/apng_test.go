@@ -0,0 +1,81 @@
+package animation
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+)
+
+// testAPNG builds an APNG of the given size with a single frame control chunk, and
+// no image data as the frame is checked before it is decoded.
+func testAPNG(width, height, frameWidth, frameHeight, x, y uint32) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(pngSignature)
+
+	ihdr := make([]byte, 13)
+	binary.BigEndian.PutUint32(ihdr[0:], width)
+	binary.BigEndian.PutUint32(ihdr[4:], height)
+	ihdr[8], ihdr[9] = 8, 6 // 8-bit RGBA
+	writeChunk(&buf, "IHDR", ihdr)
+
+	actl := make([]byte, 8)
+	binary.BigEndian.PutUint32(actl[0:], 1)
+	writeChunk(&buf, "acTL", actl)
+
+	fctl := make([]byte, 26)
+	binary.BigEndian.PutUint32(fctl[4:], frameWidth)
+	binary.BigEndian.PutUint32(fctl[8:], frameHeight)
+	binary.BigEndian.PutUint32(fctl[12:], x)
+	binary.BigEndian.PutUint32(fctl[16:], y)
+	writeChunk(&buf, "fcTL", fctl)
+
+	writeChunk(&buf, "IEND", nil)
+	return buf.Bytes()
+}
+
+func TestAPNGFrameOutsideImage(t *testing.T) {
+	for _, tc := range []struct {
+		name                string
+		width, height, x, y uint32
+	}{
+		{name: "too wide", width: 17, height: 16},
+		{name: "offset too far", width: 16, height: 16, x: 1},
+		// x+width and y+height wrap around to fit within the image
+		{name: "x overflows", width: 16, height: 16, x: 0xfffffff8},
+		{name: "width overflows", width: 0xfffffff8, height: 16, x: 8},
+		{name: "y overflows", width: 16, height: 16, y: 0xfffffff8},
+		{name: "height overflows", width: 16, height: 0xfffffff8, y: 8},
+	} {
+		_, err := decodeAPNG(testAPNG(16, 16, tc.width, tc.height, tc.x, tc.y))
+		if err == nil || !strings.Contains(err.Error(), "outside the image") {
+			t.Errorf("%s: got error %v, want the frame outside the image", tc.name, err)
+		}
+	}
+}
+
+func TestImageSize(t *testing.T) {
+	// a GIF header, a logical screen of the given size, and no frames
+	gifHeader := func(width, height uint16) []byte {
+		b := []byte("GIF89a")
+		b = binary.LittleEndian.AppendUint16(b, width)
+		b = binary.LittleEndian.AppendUint16(b, height)
+		return append(b, 0, 0, 0, 0x3b)
+	}
+
+	for _, tc := range []struct {
+		name string
+		data []byte
+	}{
+		{name: "huge apng", data: testAPNG(0x7fffffff, 0x7fffffff, 1, 1, 0, 0)},
+		{name: "apng too big", data: testAPNG(2048, 1024, 1, 1, 0, 0)},
+		{name: "empty apng", data: testAPNG(0, 16, 0, 16, 0, 0)},
+		{name: "huge gif", data: gifHeader(0xffff, 0xffff)},
+		{name: "empty gif", data: gifHeader(16, 0)},
+	} {
+		_, err := Decode(bytes.NewReader(tc.data))
+		if err == nil || !strings.Contains(err.Error(), "image too big") && !strings.Contains(err.Error(), "invalid image size") {
+			t.Errorf("%s: got error %v, want the size rejected", tc.name, err)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package animation
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+	"time"
+)
+
+func decodeGIF(data []byte) (*Animation, error) {
+	// the size is checked before the frames are decoded, as each is allocated
+	cfg, err := gif.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSize(uint64(cfg.Width), uint64(cfg.Height)); err != nil {
+		return nil, fmt.Errorf("gif: %w", err)
+	}
+
+	g, err := gif.DecodeAll(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	a := &Animation{}
+	switch {
+	case g.LoopCount < 0:
+		a.Loops = 1
+	case g.LoopCount > 0:
+		// the count is of repeats after the first play
+		a.Loops = g.LoopCount + 1
+	}
+
+	canvas := image.NewRGBA(image.Rect(0, 0, g.Config.Width, g.Config.Height))
+	for i, frame := range g.Image {
+		r := frame.Bounds()
+		if !r.In(canvas.Bounds()) {
+			return nil, fmt.Errorf("gif frame %d lies outside the image", i)
+		}
+
+		var disposal byte
+		if i < len(g.Disposal) {
+			disposal = g.Disposal[i]
+		}
+		var previous *image.RGBA
+		if disposal == gif.DisposalPrevious {
+			previous = clone(canvas)
+		}
+
+		draw.Draw(canvas, r, frame, r.Min, draw.Over)
+		a.add(clone(canvas), time.Duration(g.Delay[i])*10*time.Millisecond)
+
+		switch disposal {
+		case gif.DisposalBackground:
+			// browsers clear to transparent rather than the background colour
+			draw.Draw(canvas, r, image.Transparent, image.Point{}, draw.Src)
+		case gif.DisposalPrevious:
+			canvas = previous
+		}
+	}
+
+	if err := a.validate(); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
@@ -0,0 +1,209 @@
+package bitmapfont
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"strconv"
+	"strings"
+)
+
+// LoadBDF reads a font in the Glyph Bitmap Distribution Format, as used by the fonts
+// which come with hzeller's rpi-rgb-led-matrix library and the X11 misc-fixed fonts.
+// Glyphs without a Unicode encoding are skipped.
+func LoadBDF(name string, data []byte) (*Font, error) {
+	p := bdfParser{scanner: bufio.NewScanner(bytes.NewReader(data))}
+	font, err := p.parse(name)
+	if err != nil {
+		return nil, fmt.Errorf("bdf font %s, line %d: %w", name, p.line, err)
+	}
+	return font, nil
+}
+
+type bdfParser struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+// next returns the keyword and arguments on the next line, skipping comments and blank lines.
+func (p *bdfParser) next() (string, []string, bool) {
+	for p.scanner.Scan() {
+		p.line++
+		fields := strings.Fields(p.scanner.Text())
+		if len(fields) == 0 || fields[0] == "COMMENT" {
+			continue
+		}
+		return fields[0], fields[1:], true
+	}
+	return "", nil, false
+}
+
+func (p *bdfParser) parse(name string) (*Font, error) {
+	keyword, _, ok := p.next()
+	if !ok || keyword != "STARTFONT" {
+		return nil, fmt.Errorf("not a BDF font")
+	}
+
+	var (
+		bbox            image.Rectangle
+		ascent, descent = -1, -1
+		glyphs          = map[rune]*Glyph{}
+	)
+
+	for {
+		keyword, args, ok := p.next()
+		if !ok {
+			return nil, fmt.Errorf("missing ENDFONT")
+		}
+
+		switch keyword {
+		case "FONTBOUNDINGBOX":
+			n, err := ints(args, 4)
+			if err != nil {
+				return nil, fmt.Errorf("invalid FONTBOUNDINGBOX: %w", err)
+			}
+			bbox = bdfBounds(n)
+
+		case "FONT_ASCENT", "FONT_DESCENT":
+			n, err := ints(args, 1)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s: %w", keyword, err)
+			}
+			if keyword == "FONT_ASCENT" {
+				ascent = n[0]
+			} else {
+				descent = n[0]
+			}
+
+		case "STARTCHAR":
+			r, g, err := p.parseChar()
+			if err != nil {
+				return nil, err
+			}
+			if r >= 0 {
+				glyphs[r] = g
+			}
+
+		case "ENDFONT":
+			// fall back on the bounding box if the font doesn't give its ascent and descent
+			if ascent < 0 {
+				ascent = -bbox.Min.Y
+			}
+			if descent < 0 {
+				descent = bbox.Max.Y
+			}
+			if len(glyphs) == 0 {
+				return nil, fmt.Errorf("font has no glyphs")
+			}
+			return New(name, Metrics{Ascent: ascent, Descent: descent}, glyphs), nil
+		}
+	}
+}
+
+// parseChar reads a glyph up to ENDCHAR, returning -1 for the rune if it has no encoding.
+func (p *bdfParser) parseChar() (rune, *Glyph, error) {
+	var (
+		r       rune = -1
+		g            = &Glyph{}
+		hasBBX  bool
+		advance = -1
+	)
+
+	for {
+		keyword, args, ok := p.next()
+		if !ok {
+			return 0, nil, fmt.Errorf("missing ENDCHAR")
+		}
+
+		switch keyword {
+		case "ENCODING":
+			n, err := ints(args, 1)
+			if err != nil {
+				return 0, nil, fmt.Errorf("invalid ENCODING: %w", err)
+			}
+			r = rune(n[0])
+
+		case "DWIDTH":
+			n, err := ints(args, 2)
+			if err != nil {
+				return 0, nil, fmt.Errorf("invalid DWIDTH: %w", err)
+			}
+			advance = n[0]
+
+		case "BBX":
+			n, err := ints(args, 4)
+			if err != nil {
+				return 0, nil, fmt.Errorf("invalid BBX: %w", err)
+			}
+			g.Bounds = bdfBounds(n)
+			hasBBX = true
+
+		case "BITMAP":
+			if !hasBBX {
+				return 0, nil, fmt.Errorf("BITMAP before BBX")
+			}
+			if err := p.parseBitmap(g); err != nil {
+				return 0, nil, err
+			}
+			if advance < 0 {
+				advance = g.Bounds.Max.X
+			}
+			g.Advance = advance
+			return r, g, nil
+
+		case "ENDCHAR":
+			return 0, nil, fmt.Errorf("glyph has no BITMAP")
+		}
+	}
+}
+
+// parseBitmap reads the rows of hex following BITMAP, up to ENDCHAR.
+func (p *bdfParser) parseBitmap(g *Glyph) error {
+	w, h := g.Bounds.Dx(), g.Bounds.Dy()
+	g.Pix = make([]bool, w*h)
+
+	for y := 0; ; y++ {
+		keyword, _, ok := p.next()
+		if !ok {
+			return fmt.Errorf("missing ENDCHAR")
+		}
+		if keyword == "ENDCHAR" {
+			return nil
+		}
+		if y >= h {
+			return fmt.Errorf("glyph has more than %d bitmap rows", h)
+		}
+
+		row, err := hex.DecodeString(keyword)
+		if err != nil {
+			return fmt.Errorf("invalid bitmap row: %w", err)
+		}
+		for x := 0; x < w && x/8 < len(row); x++ {
+			g.Pix[y*w+x] = row[x/8]&(0x80>>(x%8)) != 0
+		}
+	}
+}
+
+// bdfBounds converts a BDF bounding box (width, height, x offset, y offset from the
+// baseline, with Y growing upwards) to a rectangle around the origin with Y growing downwards.
+func bdfBounds(n []int) image.Rectangle {
+	w, h, xoff, yoff := n[0], n[1], n[2], n[3]
+	return image.Rect(xoff, -(yoff + h), xoff+w, -yoff)
+}
+
+func ints(args []string, count int) ([]int, error) {
+	if len(args) < count {
+		return nil, fmt.Errorf("expected %d values, got %d", count, len(args))
+	}
+	out := make([]int, count)
+	for i := range out {
+		n, err := strconv.Atoi(args[i])
+		if err != nil {
+			return nil, err
+		}
+		out[i] = n
+	}
+	return out, nil
+}
@@ -0,0 +1,146 @@
+// Package bitmapfont draws text in pixel fonts, with per-glyph advances and a
+// baseline so fonts of different sizes can be laid out together.
+package bitmapfont
+
+import (
+	"image"
+	"image/color"
+	"unicode/utf8"
+)
+
+// Setter is a surface which text can be drawn onto.
+type Setter interface {
+	Set(x, y int, c color.Color)
+}
+
+// Glyph is the bitmap for a single character.
+type Glyph struct {
+	// Advance is how far the pen moves after drawing the glyph, including spacing.
+	Advance int
+	// Bounds is the area covered by the bitmap, relative to the pen position on the
+	// baseline. Y grows downwards, so pixels above the baseline have negative Y.
+	Bounds image.Rectangle
+	// Pix holds one entry per pixel of Bounds, row by row, true where the pixel is set.
+	Pix []bool
+}
+
+func (g *Glyph) set(x, y int) bool {
+	return g.Pix[(y-g.Bounds.Min.Y)*g.Bounds.Dx()+(x-g.Bounds.Min.X)]
+}
+
+// Metrics describe the vertical layout of a font.
+type Metrics struct {
+	// Ascent is the height of the line box above the baseline, and Descent the depth below it.
+	Ascent  int
+	Descent int
+}
+
+// Height is the height of a line of text.
+func (m Metrics) Height() int {
+	return m.Ascent + m.Descent
+}
+
+// Face is anything which can lay out and draw a line of text: a single Font, or a
+// Chain of fonts.
+type Face interface {
+	Metrics() Metrics
+	Height() int
+	Measure(text string) int
+	Bounds(text string) image.Rectangle
+	Draw(dst Setter, pos image.Point, text string, col color.Color) image.Point
+}
+
+// Font is a set of glyphs sharing metrics.
+type Font struct {
+	Name    string
+	metrics Metrics
+	glyphs  map[rune]*Glyph
+}
+
+// New creates a font from its glyphs.
+func New(name string, metrics Metrics, glyphs map[rune]*Glyph) *Font {
+	return &Font{Name: name, metrics: metrics, glyphs: glyphs}
+}
+
+// Metrics returns the font's vertical metrics.
+func (f *Font) Metrics() Metrics {
+	return f.metrics
+}
+
+// Height is the height of a line of text.
+func (f *Font) Height() int {
+	return f.metrics.Height()
+}
+
+// Glyph returns the glyph for a rune, if the font has one.
+func (f *Font) Glyph(r rune) (*Glyph, bool) {
+	g, ok := f.glyphs[r]
+	return g, ok
+}
+
+// HasGlyph reports whether the font can draw the rune.
+func (f *Font) HasGlyph(r rune) bool {
+	_, ok := f.glyphs[r]
+	return ok
+}
+
+// Measure returns the width of a line of text: the sum of its glyph advances.
+// Runes the font has no glyph for take up no space.
+func (f *Font) Measure(text string) int {
+	width := 0
+	for _, r := range text {
+		if g, ok := f.glyphs[r]; ok {
+			width += g.Advance
+		}
+	}
+	return width
+}
+
+// Bounds returns the size of the box a line of text occupies, with its top-left
+// corner at the origin.
+func (f *Font) Bounds(text string) image.Rectangle {
+	return image.Rect(0, 0, f.Measure(text), f.Height())
+}
+
+// Draw draws a line of text with the top-left corner of its line box at pos, and
+// returns the position of the pen afterwards on the same line.
+func (f *Font) Draw(dst Setter, pos image.Point, text string, col color.Color) image.Point {
+	pen := image.Point{X: pos.X, Y: pos.Y + f.metrics.Ascent}
+	for len(text) > 0 {
+		r, size := utf8.DecodeRuneInString(text)
+		text = text[size:]
+
+		g, ok := f.glyphs[r]
+		if !ok {
+			continue
+		}
+		DrawGlyph(dst, pen, g, col)
+		pen.X += g.Advance
+	}
+	return image.Point{X: pen.X, Y: pos.Y}
+}
+
+// DrawGlyph draws a single glyph with the pen at the given point on the baseline.
+func DrawGlyph(dst Setter, pen image.Point, g *Glyph, col color.Color) {
+	if rgba, ok := dst.(*image.RGBA); ok {
+		// fast path, skipping the colour model conversion for every pixel
+		c := color.RGBAModel.Convert(col).(color.RGBA)
+		b := rgba.Bounds()
+		for y := g.Bounds.Min.Y; y < g.Bounds.Max.Y; y++ {
+			for x := g.Bounds.Min.X; x < g.Bounds.Max.X; x++ {
+				if p := pen.Add(image.Pt(x, y)); g.set(x, y) && p.In(b) {
+					rgba.SetRGBA(p.X, p.Y, c)
+				}
+			}
+		}
+		return
+	}
+
+	for y := g.Bounds.Min.Y; y < g.Bounds.Max.Y; y++ {
+		for x := g.Bounds.Min.X; x < g.Bounds.Max.X; x++ {
+			if g.set(x, y) {
+				dst.Set(pen.X+x, pen.Y+y, col)
+			}
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package bitmapfont
+
+import (
+	"image"
+	"image/color"
+	"unicode"
+	"unicode/utf8"
+)
+
+// placeholderRune is drawn by a Chain in place of characters it can't otherwise show,
+// if the primary font has a glyph for it.
+const placeholderRune = '�'
+
+// Chain draws text in a primary font, taking glyphs the primary font lacks from
+// fallback fonts, then transliterating what is still missing (é to e, ß to ss), and
+// finally drawing a placeholder box, so no character is silently dropped.
+type Chain struct {
+	primary     *Font
+	fallbacks   map[rune]*Glyph // glyphs from the fallback fonts which fit the line box
+	placeholder *Glyph
+}
+
+var _ Face = (*Chain)(nil)
+
+// NewChain creates a Chain with the primary font's metrics. Glyphs are only taken
+// from a fallback font if they fit within the primary font's line box, which is
+// worked out here once rather than each time text is drawn.
+func NewChain(primary *Font, fallbacks ...*Font) *Chain {
+	c := &Chain{primary: primary, fallbacks: map[rune]*Glyph{}}
+
+	m := primary.metrics
+	for _, f := range fallbacks {
+		if f == nil || f == primary {
+			continue
+		}
+		for r, g := range f.glyphs {
+			if _, ok := primary.glyphs[r]; ok {
+				continue
+			}
+			if _, ok := c.fallbacks[r]; ok {
+				continue
+			}
+			if ink := g.ink(); ink.Min.Y >= -m.Ascent && ink.Max.Y <= m.Descent {
+				c.fallbacks[r] = g
+			}
+		}
+	}
+
+	if g, ok := primary.Glyph(placeholderRune); ok {
+		c.placeholder = g
+	} else {
+		c.placeholder = boxGlyph(primary)
+	}
+
+	return c
+}
+
+// Primary returns the font the chain draws in where it can.
+func (c *Chain) Primary() *Font {
+	return c.primary
+}
+
+// HasGlyph reports whether the chain can draw the rune from one of its fonts,
+// without transliterating it or drawing a placeholder.
+func (c *Chain) HasGlyph(r rune) bool {
+	_, ok := c.glyph(r)
+	return ok
+}
+
+// Metrics returns the primary font's vertical metrics.
+func (c *Chain) Metrics() Metrics {
+	return c.primary.Metrics()
+}
+
+// Height is the height of a line of text.
+func (c *Chain) Height() int {
+	return c.primary.Height()
+}
+
+// Measure returns the width of a line of text, as it would be drawn.
+func (c *Chain) Measure(text string) int {
+	width := 0
+	c.glyphs(text, func(g *Glyph) {
+		width += g.Advance
+	})
+	return width
+}
+
+// Bounds returns the size of the box a line of text occupies, with its top-left
+// corner at the origin.
+func (c *Chain) Bounds(text string) image.Rectangle {
+	return image.Rect(0, 0, c.Measure(text), c.Height())
+}
+
+// Draw draws a line of text with the top-left corner of its line box at pos, and
+// returns the position of the pen afterwards on the same line.
+func (c *Chain) Draw(dst Setter, pos image.Point, text string, col color.Color) image.Point {
+	pen := image.Point{X: pos.X, Y: pos.Y + c.primary.metrics.Ascent}
+	c.glyphs(text, func(g *Glyph) {
+		DrawGlyph(dst, pen, g, col)
+		pen.X += g.Advance
+	})
+	return image.Point{X: pen.X, Y: pos.Y}
+}
+
+// glyphs calls fn with the glyph for each character of the text, in order. A
+// transliterated character may produce several glyphs, or none.
+func (c *Chain) glyphs(text string, fn func(g *Glyph)) {
+	for len(text) > 0 {
+		r, size := utf8.DecodeRuneInString(text)
+		text = text[size:]
+
+		if g, ok := c.glyph(r); ok {
+			fn(g)
+			continue
+		}
+
+		if s, ok := Transliterate(r); ok && c.canDraw(s) {
+			for _, tr := range s {
+				g, _ := c.glyph(tr)
+				fn(g)
+			}
+			continue
+		}
+
+		// control characters such as newlines aren't drawn in fonts which lack them
+		if unicode.IsControl(r) {
+			continue
+		}
+		fn(c.placeholder)
+	}
+}
+
+// glyph finds a glyph in the primary font, or the first fallback font it fits in.
+func (c *Chain) glyph(r rune) (*Glyph, bool) {
+	if g, ok := c.primary.Glyph(r); ok {
+		return g, true
+	}
+
+	g, ok := c.fallbacks[r]
+	return g, ok
+}
+
+func (c *Chain) canDraw(s string) bool {
+	for _, r := range s {
+		if _, ok := c.glyph(r); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// ink returns the area of the glyph's bitmap with pixels set, which may be smaller
+// than its bounds.
+func (g *Glyph) ink() image.Rectangle {
+	ink := image.Rectangle{}
+	for y := g.Bounds.Min.Y; y < g.Bounds.Max.Y; y++ {
+		for x := g.Bounds.Min.X; x < g.Bounds.Max.X; x++ {
+			if g.set(x, y) {
+				ink = ink.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+	return ink
+}
+
+// boxGlyph draws a hollow box the height of the font's capital letters, or of its
+// ascent if it has none, as a placeholder.
+func boxGlyph(f *Font) *Glyph {
+	height := f.metrics.Ascent
+	if g, ok := f.Glyph('H'); ok {
+		if ink := g.ink(); !ink.Empty() {
+			height = -ink.Min.Y
+		}
+	}
+	height = max(height, 3)
+	width := max(height*3/5, 3)
+
+	g := &Glyph{
+		Advance: width + 1,
+		Bounds:  image.Rect(0, -height, width, 0),
+		Pix:     make([]bool, width*height),
+	}
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			g.Pix[y*width+x] = x == 0 || x == width-1 || y == 0 || y == height-1
+		}
+	}
+
+	return g
+}
@@ -0,0 +1,58 @@
+package bitmapfont
+
+import (
+	"image"
+	"testing"
+)
+
+// solidGlyph is a glyph with every pixel of its bounds set.
+func solidGlyph(bounds image.Rectangle) *Glyph {
+	return &Glyph{Advance: bounds.Dx() + 1, Bounds: bounds, Pix: func() []bool {
+		pix := make([]bool, bounds.Dx()*bounds.Dy())
+		for i := range pix {
+			pix[i] = true
+		}
+		return pix
+	}()}
+}
+
+func TestChainFallbacks(t *testing.T) {
+	primary := New("primary", Metrics{Ascent: 6, Descent: 2}, map[rune]*Glyph{
+		'a': solidGlyph(image.Rect(0, -5, 3, 0)),
+	})
+	// glyphs which fit the primary line box, and one too tall, in a bitmap with
+	// blank rows above it which doesn't count
+	tall := New("tall", Metrics{Ascent: 11, Descent: 2}, map[rune]*Glyph{
+		'a': solidGlyph(image.Rect(0, -9, 5, 0)),
+		'b': solidGlyph(image.Rect(0, -9, 5, 0)),
+		'c': {Advance: 4, Bounds: image.Rect(0, -11, 3, 0), Pix: append(make([]bool, 3*6), solidGlyph(image.Rect(0, -5, 3, 0)).Pix...)},
+		'd': solidGlyph(image.Rect(0, -6, 4, 2)),
+	})
+	small := New("small", Metrics{Ascent: 6, Descent: 2}, map[rune]*Glyph{
+		'b': solidGlyph(image.Rect(0, -4, 2, 0)),
+		'c': solidGlyph(image.Rect(0, -4, 2, 0)),
+	})
+
+	c := NewChain(primary, tall, small)
+	for _, tc := range []struct {
+		r    rune
+		font *Font
+	}{
+		{'a', primary},
+		{'b', small}, // too tall in the first fallback
+		{'c', tall},  // fits the first fallback, once its blank rows are left out
+		{'d', tall},
+		{'e', nil},
+	} {
+		g, ok := c.glyph(tc.r)
+		if tc.font == nil {
+			if ok {
+				t.Errorf("%q: found a glyph, want none", tc.r)
+			}
+			continue
+		}
+		if want, _ := tc.font.Glyph(tc.r); g != want {
+			t.Errorf("%q: took the glyph from the wrong font, want %s", tc.r, tc.font.Name)
+		}
+	}
+}
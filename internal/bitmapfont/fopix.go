@@ -0,0 +1,116 @@
+package bitmapfont
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"unicode/utf8"
+
+	"github.com/toelsiba/fopix"
+)
+
+// FopixOptions describe what the fopix JSON format leaves out.
+type FopixOptions struct {
+	// Baseline is the row of the character cell the baseline sits on: the first row
+	// below the capital letters. Zero puts it at the bottom of the cell.
+	Baseline int
+	// Proportional trims each glyph to the pixels it sets, plus Spacing, rather than
+	// giving every glyph the full cell width. Spaces keep the cell width.
+	Proportional bool
+	Spacing      int
+	// TabularDigits gives the digits of a proportional font the same width, so
+	// numbers such as the time don't shift about as they change.
+	TabularDigits bool
+}
+
+// LoadFopix reads a font in the fopix JSON format. Glyphs are placed in the same
+// position within the line box as fopix would draw them in its character cell.
+func LoadFopix(data []byte, opts FopixOptions) (*Font, error) {
+	var info fopix.FontInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		return nil, fmt.Errorf("error decoding fopix font: %w", err)
+	}
+	if info.Size.X <= 0 || info.Size.Y <= 0 {
+		return nil, fmt.Errorf("fopix font %q has no size", info.Name)
+	}
+
+	baseline := opts.Baseline
+	if baseline <= 0 {
+		baseline = info.Size.Y
+	}
+	if baseline > info.Size.Y {
+		return nil, fmt.Errorf("fopix font %q baseline %d is below its cell", info.Name, baseline)
+	}
+
+	glyphs := make(map[rune]*Glyph, len(info.CharSet))
+	inks := make(map[rune]image.Rectangle, len(info.CharSet))
+	digits := image.Rectangle{}
+	for _, ri := range info.CharSet {
+		r := rune(ri.Character)
+		if _, dup := glyphs[r]; dup {
+			return nil, fmt.Errorf("fopix font %q has duplicate glyph %q", info.Name, r)
+		}
+		glyphs[r], inks[r] = fopixGlyph(info, ri.Bitmap, baseline)
+		if r >= '0' && r <= '9' {
+			digits = digits.Union(inks[r])
+		}
+	}
+
+	if opts.Proportional {
+		for r, g := range glyphs {
+			ink := inks[r]
+			if opts.TabularDigits && r >= '0' && r <= '9' {
+				ink = digits
+			}
+			if !ink.Empty() {
+				trim(g, ink, opts.Spacing)
+			}
+		}
+	}
+
+	return New(info.Name, Metrics{Ascent: baseline, Descent: info.Size.Y - baseline}, glyphs), nil
+}
+
+// fopixGlyph converts a glyph's rows of characters into a bitmap, shifting it by
+// the font's anchor position as fopix does. It also returns the area of the cell
+// the glyph sets pixels in.
+func fopixGlyph(info fopix.FontInfo, lines []string, baseline int) (*Glyph, image.Rectangle) {
+	size := image.Pt(info.Size.X, info.Size.Y)
+	g := &Glyph{
+		Advance: size.X,
+		Bounds:  image.Rect(0, -baseline, size.X, size.Y-baseline),
+		Pix:     make([]bool, size.X*size.Y),
+	}
+
+	ink := image.Rectangle{}
+	for row, line := range lines {
+		for col := 0; col < size.X && len(line) > 0; col++ {
+			r, n := utf8.DecodeRuneInString(line)
+			line = line[n:]
+			if r != rune(info.TargetChar) {
+				continue
+			}
+			x, y := col+info.AnchorPos.X, row+info.AnchorPos.Y
+			if x < 0 || x >= size.X || y < 0 || y >= size.Y {
+				continue
+			}
+			g.Pix[y*size.X+x] = true
+			ink = ink.Union(image.Rect(x, y, x+1, y+1))
+		}
+	}
+
+	return g, ink
+}
+
+// trim narrows a glyph to the columns of ink, keeping its full height so the bitmap
+// still lines up with the baseline.
+func trim(g *Glyph, ink image.Rectangle, spacing int) {
+	width, height := g.Bounds.Dx(), g.Bounds.Dy()
+	trimmed := make([]bool, 0, ink.Dx()*height)
+	for y := 0; y < height; y++ {
+		trimmed = append(trimmed, g.Pix[y*width+ink.Min.X:y*width+ink.Max.X]...)
+	}
+	g.Pix = trimmed
+	g.Bounds = image.Rect(0, g.Bounds.Min.Y, ink.Dx(), g.Bounds.Max.Y)
+	g.Advance = ink.Dx() + spacing
+}
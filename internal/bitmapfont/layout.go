@@ -0,0 +1,212 @@
+package bitmapfont
+
+import (
+	"image"
+	"image/color"
+	"strings"
+)
+
+// Align is the horizontal alignment of text within a rectangle.
+type Align int
+
+const (
+	AlignLeft Align = iota
+	AlignCentre
+	AlignRight
+)
+
+// VAlign is the vertical alignment of a block of lines within a rectangle.
+type VAlign int
+
+const (
+	VAlignTop VAlign = iota
+	VAlignMiddle
+	VAlignBottom
+)
+
+// ellipsis ends text cut short to fit, when the face can draw it.
+const ellipsis = "…"
+
+// Layout places text within a rectangle. The zero value draws a single line at the
+// top-left of the rectangle, clipped to it.
+type Layout struct {
+	Align  Align
+	VAlign VAlign
+	// Wrap breaks text onto as many lines as fit in the rectangle, between words
+	// where it can. Otherwise text is drawn on a single line.
+	Wrap bool
+	// Ellipsis ends text which doesn't fit with "…", rather than clipping it.
+	Ellipsis bool
+	// LineSpacing is the gap between wrapped lines.
+	LineSpacing int
+}
+
+// Lines splits text into the lines it would be drawn as in a rectangle of the given
+// size, applying the wrapping and ellipsis settings.
+func (l Layout) Lines(face Face, size image.Point, text string) []string {
+	maxLines := 1
+	if l.Wrap {
+		maxLines = max(1, (size.Y+l.LineSpacing)/(face.Height()+l.LineSpacing))
+	}
+
+	var lines []string
+	if l.Wrap {
+		lines = Wrap(face, text, size.X)
+	} else {
+		lines = []string{text}
+	}
+
+	if len(lines) > maxLines {
+		rest := strings.Join(lines[maxLines-1:], " ")
+		lines = lines[:maxLines]
+		lines[maxLines-1] = rest
+	}
+
+	if l.Ellipsis {
+		for i, line := range lines {
+			lines[i] = Truncate(face, line, size.X)
+		}
+	}
+
+	return lines
+}
+
+// Fits reports whether text can be drawn in a rectangle of the given size without
+// being clipped or shortened.
+func (l Layout) Fits(face Face, size image.Point, text string) bool {
+	l.Ellipsis = false
+	lines := l.Lines(face, size, text)
+	if len(lines)*(face.Height()+l.LineSpacing)-l.LineSpacing > size.Y {
+		return false
+	}
+	for _, line := range lines {
+		if face.Measure(line) > size.X {
+			return false
+		}
+	}
+	return true
+}
+
+// Draw draws text within rect, clipped to it, and returns the number of lines drawn.
+func (l Layout) Draw(dst Setter, face Face, rect image.Rectangle, text string, col color.Color) int {
+	lines := l.Lines(face, rect.Size(), text)
+	lineHeight := face.Height() + l.LineSpacing
+	blockHeight := len(lines)*lineHeight - l.LineSpacing
+
+	y := rect.Min.Y
+	switch l.VAlign {
+	case VAlignMiddle:
+		y += (rect.Dy() - blockHeight) / 2
+	case VAlignBottom:
+		y += rect.Dy() - blockHeight
+	}
+
+	clipped := Clip(dst, rect)
+	for _, line := range lines {
+		x := rect.Min.X + AlignOffset(face.Measure(line), rect.Dx(), l.Align)
+		face.Draw(clipped, image.Pt(x, y), line, col)
+		y += lineHeight
+	}
+
+	return len(lines)
+}
+
+// AlignOffset returns how far from the left of a box of the given width text of
+// the given width should start. Text wider than the box starts at the left.
+func AlignOffset(textWidth, boxWidth int, a Align) int {
+	if textWidth >= boxWidth {
+		return 0
+	}
+	switch a {
+	case AlignCentre:
+		return (boxWidth - textWidth) / 2
+	case AlignRight:
+		return boxWidth - textWidth
+	default:
+		return 0
+	}
+}
+
+// Wrap breaks text into lines no wider than width, between words where possible.
+// Words too long for a line of their own are broken between characters.
+func Wrap(face Face, text string, width int) []string {
+	var (
+		lines []string
+		line  string
+	)
+
+	for _, word := range strings.Fields(text) {
+		candidate := word
+		if line != "" {
+			candidate = line + " " + word
+		}
+		if face.Measure(candidate) <= width {
+			line = candidate
+			continue
+		}
+
+		if line != "" {
+			lines = append(lines, line)
+		}
+		line = word
+		for face.Measure(line) > width {
+			head, tail := splitAt(face, line, width)
+			lines = append(lines, head)
+			line = tail
+		}
+	}
+
+	if line != "" || len(lines) == 0 {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// splitAt splits text after as many characters as fit in width, always taking at
+// least one so wrapping makes progress.
+func splitAt(face Face, text string, width int) (string, string) {
+	runes := []rune(text)
+	n := 1
+	for n < len(runes) && face.Measure(string(runes[:n+1])) <= width {
+		n++
+	}
+	return string(runes[:n]), string(runes[n:])
+}
+
+// Truncate shortens text which is wider than width, ending it with an ellipsis.
+// Trailing spaces are dropped before the ellipsis.
+func Truncate(face Face, text string, width int) string {
+	if face.Measure(text) <= width {
+		return text
+	}
+
+	mark := ellipsis
+	if g, ok := face.(interface{ HasGlyph(rune) bool }); ok && !g.HasGlyph('…') {
+		mark = "..."
+	}
+
+	runes := []rune(text)
+	for n := len(runes) - 1; n > 0; n-- {
+		s := strings.TrimRight(string(runes[:n]), " ") + mark
+		if face.Measure(s) <= width {
+			return s
+		}
+	}
+	return mark
+}
+
+// Clip restricts drawing on dst to rect.
+func Clip(dst Setter, rect image.Rectangle) Setter {
+	return clipped{dst: dst, rect: rect}
+}
+
+type clipped struct {
+	dst  Setter
+	rect image.Rectangle
+}
+
+func (c clipped) Set(x, y int, col color.Color) {
+	if image.Pt(x, y).In(c.rect) {
+		c.dst.Set(x, y, col)
+	}
+}
@@ -0,0 +1,214 @@
+// Package brightness eases the display brightness up and down over the day,
+// following a curve of levels set at fixed times or relative to sunrise and sunset.
+package brightness
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/g-wilson/led/internal/schedule"
+)
+
+// Point is a brightness level, as a percentage, reached at a time of day.
+type Point struct {
+	At    schedule.TimeSpec
+	Level int
+}
+
+// Curve is a set of points which the brightness eases between over the day.
+// After the last point of the day it eases towards the first point of the next.
+type Curve []Point
+
+// ParseCurve parses a comma-separated list of time=level pairs, for example
+// "sunrise-30m=10,sunrise+1h=80,sunset=60,22:00=15". Times take any form accepted
+// by schedule.ParseTimeSpec, and levels are percentages.
+func ParseCurve(s string) (Curve, error) {
+	var c Curve
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		at, level, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid curve point %q: expected time=level", pair)
+		}
+		spec, err := schedule.ParseTimeSpec(at)
+		if err != nil {
+			return nil, fmt.Errorf("invalid curve point %q: %w", pair, err)
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(level))
+		if err != nil || n < 0 || n > 100 {
+			return nil, fmt.Errorf("invalid curve point %q: level must be 0 to 100", pair)
+		}
+		c = append(c, Point{At: spec, Level: n})
+	}
+	if len(c) == 0 {
+		return nil, fmt.Errorf("brightness curve has no points")
+	}
+	return c, nil
+}
+
+// Options configures a Controller.
+type Options struct {
+	// Curve sets the level through the day. Without one, the level stays at Max
+	// unless it is overridden.
+	Curve Curve
+	// Min and Max clamp the level the curve produces, as percentages.
+	Min int
+	Max int
+	// Sun resolves sunrise and sunset for curve points relative to them.
+	Sun      schedule.SunProvider
+	Location *time.Location
+}
+
+// Controller works out the brightness for the current time, and applies it to
+// frames in software so that every output shows the same result.
+type Controller struct {
+	curve    Curve
+	min      int
+	max      int
+	sun      schedule.SunProvider
+	location *time.Location
+
+	mu       sync.Mutex
+	override int        // manually set level, -1 to follow the curve
+	lut      [256]uint8 // channel scaling table for level
+	level    int        // level lut was built for, -1 before the first frame
+}
+
+// New creates a Controller, checking the options are sensible.
+func New(opts Options) (*Controller, error) {
+	if opts.Min < 0 || opts.Max > 100 || opts.Min > opts.Max {
+		return nil, fmt.Errorf("invalid brightness limits %d-%d: expected 0 <= min <= max <= 100", opts.Min, opts.Max)
+	}
+	if len(opts.Curve) > 0 && opts.Sun == nil {
+		return nil, fmt.Errorf("brightness controller needs a sunrise and sunset provider")
+	}
+
+	loc := opts.Location
+	if loc == nil {
+		loc = time.Local
+	}
+
+	return &Controller{
+		curve:    opts.Curve,
+		min:      opts.Min,
+		max:      opts.Max,
+		sun:      opts.Sun,
+		location: loc,
+		override: -1,
+		level:    -1,
+	}, nil
+}
+
+// SetOverride holds the brightness at the given percentage, ignoring the curve and
+// its limits, until ClearOverride is called.
+func (c *Controller) SetOverride(level int) error {
+	if level < 0 || level > 100 {
+		return fmt.Errorf("invalid brightness %d: expected 0 to 100", level)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.override = level
+	return nil
+}
+
+// ClearOverride returns the brightness to following the curve.
+func (c *Controller) ClearOverride() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.override = -1
+}
+
+// Override returns the manually set level, if there is one.
+func (c *Controller) Override() (int, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.override, c.override >= 0
+}
+
+// Level returns the brightness percentage for the given time.
+func (c *Controller) Level(now time.Time) int {
+	if level, ok := c.Override(); ok {
+		return level
+	}
+	if len(c.curve) == 0 {
+		return c.max
+	}
+
+	return c.curveLevel(now.In(c.location))
+}
+
+// curveLevel eases between the curve points either side of now.
+func (c *Controller) curveLevel(now time.Time) int {
+	// resolve the curve over three days so there is always a point either side of now
+	type resolved struct {
+		at    time.Time
+		level int
+	}
+	points := make([]resolved, 0, 3*len(c.curve))
+	for _, offset := range []int{-1, 0, 1} {
+		day := now.AddDate(0, 0, offset)
+		for _, p := range c.curve {
+			points = append(points, resolved{at: p.At.On(day, c.sun), level: p.Level})
+		}
+	}
+	sort.SliceStable(points, func(i, j int) bool { return points[i].at.Before(points[j].at) })
+
+	level := float64(points[0].level)
+	for i := 1; i < len(points); i++ {
+		if points[i].at.After(now) {
+			prev, next := points[i-1], points[i]
+			span := next.at.Sub(prev.at)
+			t := 1.0
+			if span > 0 {
+				t = float64(now.Sub(prev.at)) / float64(span)
+			}
+			level = float64(prev.level) + (float64(next.level)-float64(prev.level))*ease(t)
+			break
+		}
+	}
+
+	return min(max(int(math.Round(level)), c.min), c.max)
+}
+
+// ease is a cosine ease, so the brightness changes gently at each end of a step.
+func ease(t float64) float64 {
+	return (1 - math.Cos(math.Pi*t)) / 2
+}
+
+// Filter scales the frame by the current brightness level.
+// It implements framestreamer.Filter.
+func (c *Controller) Filter(frame *image.RGBA) {
+	level := c.Level(time.Now())
+	if level >= 100 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if level != c.level {
+		for i := range c.lut {
+			c.lut[i] = uint8((i*level + 50) / 100)
+		}
+		c.level = level
+	}
+
+	for i := 0; i < len(frame.Pix); i += 4 {
+		frame.Pix[i] = c.lut[frame.Pix[i]]
+		frame.Pix[i+1] = c.lut[frame.Pix[i+1]]
+		frame.Pix[i+2] = c.lut[frame.Pix[i+2]]
+	}
+}
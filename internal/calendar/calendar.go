@@ -1,11 +1,8 @@
 package calendar
 
 import (
-	"bytes"
-	_ "embed"
+	"embed"
 	"fmt"
-	"image"
-	_ "image/png"
 	"log"
 	"os"
 	"path/filepath"
@@ -14,16 +11,22 @@ import (
 	"time"
 
 	"github.com/g-wilson/led/calendars"
+	"github.com/g-wilson/led/internal/animation"
 	"gopkg.in/yaml.v3"
 )
 
-//go:embed images/xmastree.png
-var xmasImageSource []byte
+//go:embed images
+var imageFiles embed.FS
 
-//go:embed images/f1.png
-var f1ImageSource []byte
+// builtinImageFiles are the images events can use with the "builtin:" prefix.
+var builtinImageFiles = map[string]string{
+	"f1":                "images/f1.png",
+	"f1-animated":       "images/f1-animated.png",
+	"xmastree":          "images/xmastree.png",
+	"xmastree-animated": "images/xmastree-animated.gif",
+}
 
-var builtinImages map[string]image.Image
+var builtinImages map[string]*animation.Animation
 
 var sortedEvents eventList
 
@@ -31,7 +34,7 @@ type Event struct {
 	Name      string
 	Timestamp string
 	StartsAt  time.Time
-	Image     image.Image
+	Image     *animation.Animation
 }
 
 func (e *Event) Until() time.Duration {
@@ -55,19 +58,18 @@ func (s eventList) Less(i, j int) bool {
 // Load initialises the calendar. It parses the embedded default event files
 // and any additional YAML files provided in the files slice.
 func Load(files []string) error {
-	f1Img, _, err := image.Decode(bytes.NewReader(f1ImageSource))
-	if err != nil {
-		return fmt.Errorf("calendar: failed to decode builtin f1 image: %w", err)
-	}
-
-	xmasImg, _, err := image.Decode(bytes.NewReader(xmasImageSource))
-	if err != nil {
-		return fmt.Errorf("calendar: failed to decode builtin xmastree image: %w", err)
-	}
-
-	builtinImages = map[string]image.Image{
-		"f1":       f1Img,
-		"xmastree": xmasImg,
+	builtinImages = make(map[string]*animation.Animation, len(builtinImageFiles))
+	for alias, file := range builtinImageFiles {
+		f, err := imageFiles.Open(file)
+		if err != nil {
+			return fmt.Errorf("calendar: failed to open builtin %s image: %w", alias, err)
+		}
+		img, err := animation.Decode(f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("calendar: failed to decode builtin %s image: %w", alias, err)
+		}
+		builtinImages[alias] = img
 	}
 
 	defaults, err := loadYAMLBytes(calendars.EventsYAML, "")
@@ -144,7 +146,9 @@ func loadYAMLBytes(data []byte, dir string) (eventList, error) {
 	return result, nil
 }
 
-func resolveImage(ref string, dir string) image.Image {
+// resolveImage loads an event's image, which may be animated, from a file or the
+// builtin images.
+func resolveImage(ref string, dir string) *animation.Animation {
 	if ref == "" {
 		return nil
 	}
@@ -170,7 +174,7 @@ func resolveImage(ref string, dir string) image.Image {
 	}
 	defer f.Close()
 
-	img, _, err := image.Decode(f)
+	img, err := animation.Decode(f)
 	if err != nil {
 		log.Printf("calendar: cannot decode image %q: %v", ref, err)
 		return nil
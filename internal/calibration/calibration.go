@@ -0,0 +1,197 @@
+// Package calibration corrects frames for the panel they are shown on. Colours which
+// look right on a monitor tend to look washed out or tinted on LED panels, whose
+// output is linear in the PWM level and whose red, green and blue LEDs differ in
+// strength, so frames are passed through gamma curves and white balance gains
+// before they are output, with a floor so dim colours don't disappear altogether.
+//
+// Optionally, the calibration dithers its output over time: levels between the 256
+// a frame can hold are shown by alternating between the levels either side of them
+// from one frame to the next, which smooths the banding of gradients and dim
+// colours at low brightness. It needs frames sent far faster than pages are drawn.
+package calibration
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+// Leveler gives the brightness, as a percentage, for a time.
+type Leveler interface {
+	Level(now time.Time) int
+}
+
+// Options configures a Calibration. Gamma and Gains take either one value for all
+// three channels, or one each for red, green and blue. Left empty, they default to
+// 1, which leaves the channels as they are.
+type Options struct {
+	// Gamma is the exponent each channel is raised to. Around 2.2 brings colours
+	// chosen on a monitor closer to how they look on a panel.
+	Gamma []float64
+	// Gains scale each channel after the gamma curve, from 0 to 1, to balance
+	// the white point of panels with a colour cast.
+	Gains []float64
+	// Floor is the lowest level, from 0 to 255, that a lit pixel is shown at.
+	// Pixels dimmer than it are brightened to it, keeping their colour, rather
+	// than fading out at low brightness.
+	Floor int
+	// Dither spreads each pixel's rounding error over the frames which follow it,
+	// rather than rounding every frame the same way.
+	Dither bool
+	// Brightness, if set, scales frames by its level before the floor, at full
+	// precision so dithering can show levels in between. It takes the place of a
+	// separate brightness stage.
+	Brightness Leveler
+}
+
+// Calibration applies gamma curves, white balance gains and a floor to frames.
+type Calibration struct {
+	lut      [3][256]uint16 // per channel, 16-bit so the floor can recover dim colours
+	floor    uint32         // floor on the 16-bit scale
+	identity bool           // the options leave frames unchanged
+	disabled atomic.Bool
+
+	dither     bool
+	errors     []int32 // rounding error carried over from the last frame, per channel of each pixel
+	brightness Leveler
+}
+
+// New creates a Calibration, checking the options are sensible.
+func New(opts Options) (*Calibration, error) {
+	gamma, err := channels("gamma", opts.Gamma)
+	if err != nil {
+		return nil, err
+	}
+	gains, err := channels("gains", opts.Gains)
+	if err != nil {
+		return nil, err
+	}
+	for _, g := range gamma {
+		if g <= 0 || g > 5 {
+			return nil, fmt.Errorf("invalid gamma %g: expected more than 0, up to 5", g)
+		}
+	}
+	for _, g := range gains {
+		if g < 0 || g > 1 {
+			return nil, fmt.Errorf("invalid gain %g: expected 0 to 1", g)
+		}
+	}
+	if opts.Floor < 0 || opts.Floor > 255 {
+		return nil, fmt.Errorf("invalid floor %d: expected 0 to 255", opts.Floor)
+	}
+
+	c := &Calibration{
+		floor:      uint32(opts.Floor) * 257,
+		identity:   opts.Floor == 0 && !opts.Dither && opts.Brightness == nil,
+		dither:     opts.Dither,
+		brightness: opts.Brightness,
+	}
+	for ch := range c.lut {
+		if gamma[ch] != 1 || gains[ch] != 1 {
+			c.identity = false
+		}
+		for i := range c.lut[ch] {
+			v := math.Round(65535 * gains[ch] * math.Pow(float64(i)/255, gamma[ch]))
+			// keep lit pixels lit, however dim, so the floor has something to work with
+			if i > 0 && gains[ch] > 0 && v < 1 {
+				v = 1
+			}
+			c.lut[ch][i] = uint16(v)
+		}
+	}
+
+	return c, nil
+}
+
+// channels expands a setting given once for all channels, or once per channel.
+func channels(name string, values []float64) ([3]float64, error) {
+	switch len(values) {
+	case 0:
+		return [3]float64{1, 1, 1}, nil
+	case 1:
+		return [3]float64{values[0], values[0], values[0]}, nil
+	case 3:
+		return [3]float64{values[0], values[1], values[2]}, nil
+	}
+	return [3]float64{}, fmt.Errorf("invalid %s: expected one value, or three for red, green and blue", name)
+}
+
+// SetEnabled turns the calibration on or off, for comparing frames with and without it.
+func (c *Calibration) SetEnabled(enabled bool) {
+	c.disabled.Store(!enabled)
+}
+
+// Enabled reports whether the calibration is being applied.
+func (c *Calibration) Enabled() bool {
+	return !c.disabled.Load()
+}
+
+// Filter applies the calibration to the frame.
+// It implements framestreamer.Filter.
+func (c *Calibration) Filter(frame *image.RGBA) {
+	level := uint32(100)
+	if c.brightness != nil {
+		level = uint32(c.brightness.Level(time.Now()))
+	}
+
+	if c.disabled.Load() {
+		// still apply the brightness, so only the calibration is missing
+		if level < 100 {
+			for i := 0; i+3 < len(frame.Pix); i += 4 {
+				frame.Pix[i] = uint8((uint32(frame.Pix[i])*level + 50) / 100)
+				frame.Pix[i+1] = uint8((uint32(frame.Pix[i+1])*level + 50) / 100)
+				frame.Pix[i+2] = uint8((uint32(frame.Pix[i+2])*level + 50) / 100)
+			}
+		}
+		return
+	}
+	if c.identity {
+		return
+	}
+
+	if c.dither && len(c.errors) != len(frame.Pix) {
+		c.errors = make([]int32, len(frame.Pix))
+	}
+
+	for i := 0; i+3 < len(frame.Pix); i += 4 {
+		r := uint32(c.lut[0][frame.Pix[i]])
+		g := uint32(c.lut[1][frame.Pix[i+1]])
+		b := uint32(c.lut[2][frame.Pix[i+2]])
+
+		if level < 100 {
+			r = r * level / 100
+			g = g * level / 100
+			b = b * level / 100
+		}
+
+		// brighten the whole pixel, so its colour holds as it reaches the floor
+		if m := max(r, g, b); m > 0 && m < c.floor {
+			r = r * c.floor / m
+			g = g * c.floor / m
+			b = b * c.floor / m
+		}
+
+		if c.dither {
+			frame.Pix[i] = c.quantise(i, r)
+			frame.Pix[i+1] = c.quantise(i+1, g)
+			frame.Pix[i+2] = c.quantise(i+2, b)
+			continue
+		}
+
+		frame.Pix[i] = uint8((r + 128) / 257)
+		frame.Pix[i+1] = uint8((g + 128) / 257)
+		frame.Pix[i+2] = uint8((b + 128) / 257)
+	}
+}
+
+// quantise rounds a 16-bit channel value to 8 bits, adding the rounding error left
+// over from the same channel in the last frame and keeping the new error for the
+// next, so that over successive frames the channel averages out at its true level.
+func (c *Calibration) quantise(i int, v uint32) uint8 {
+	want := int32(v) + c.errors[i]
+	out := min(max((want+128)/257, 0), 255)
+	c.errors[i] = want - out*257
+	return uint8(out)
+}
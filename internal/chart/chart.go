@@ -0,0 +1,233 @@
+// Package chart draws small charts of series of values, such as those kept by the
+// history package: sparklines, bar charts, min/max bands and gauges. Charts fit
+// their data to the rectangle they are given, a column of pixels at a time, with
+// the newest values on the right.
+package chart
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/g-wilson/led/internal/gfx"
+)
+
+// Ramp picks the colour for a value, given its position within the chart's scale
+// from 0 at the bottom to 1 at the top.
+type Ramp func(t float64) color.RGBA
+
+// Solid colours every value the same.
+func Solid(col color.RGBA) Ramp {
+	return func(float64) color.RGBA { return col }
+}
+
+// Gradient blends between colours spread evenly across the scale, from the
+// bottom to the top.
+func Gradient(stops ...color.RGBA) Ramp {
+	return func(t float64) color.RGBA {
+		if len(stops) == 1 {
+			return stops[0]
+		}
+		t = clamp(t) * float64(len(stops)-1)
+		i := min(int(t), len(stops)-2)
+		return mix(stops[i], stops[i+1], t-float64(i))
+	}
+}
+
+// Scale is the range of values a chart shows. If Min and Max are equal, the chart
+// scales itself to fit its data.
+type Scale struct {
+	Min, Max float64
+	// IncludeZero extends an automatic scale to include zero, so bars are in proportion.
+	IncludeZero bool
+}
+
+// Options style a chart.
+type Options struct {
+	Scale Scale
+	// Ramp colours the chart. Defaults to white.
+	Ramp Ramp
+	// Background fills the area behind the chart, if it is not transparent.
+	Background color.RGBA
+}
+
+// Sparkline draws a line through the values, one point per column.
+func Sparkline(c *gfx.Canvas, r image.Rectangle, values []float64, opts Options) {
+	cols, lo, hi, ok := prepare(c, r, values, opts)
+	if !ok {
+		return
+	}
+	ramp := opts.ramp()
+
+	prevX, prevY := 0.0, 0.0
+	for i, col := range cols {
+		t := position(mean(col), lo, hi)
+		x, y := float64(columnX(r, len(cols), i))+0.5, rowY(r, t)
+		if i == 0 {
+			c.Line(x, y, x, y, 1, ramp(t))
+		} else {
+			c.Line(prevX, prevY, x, y, 1, ramp(t))
+		}
+		prevX, prevY = x, y
+	}
+}
+
+// Bars draws a bar for each column, rising from the bottom of the chart, or from
+// zero if the scale includes it.
+func Bars(c *gfx.Canvas, r image.Rectangle, values []float64, opts Options) {
+	cols, lo, hi, ok := prepare(c, r, values, opts)
+	if !ok {
+		return
+	}
+	ramp := opts.ramp()
+	base := rowY(r, position(math.Max(lo, math.Min(hi, 0)), lo, hi))
+
+	for i, col := range cols {
+		t := position(mean(col), lo, hi)
+		x := columnX(r, len(cols), i)
+		top, bottom := math.Min(rowY(r, t), base), math.Max(rowY(r, t), base)
+		c.FillRect(image.Rect(x, int(math.Floor(top)), x+1, int(math.Floor(bottom))+1), ramp(t))
+	}
+}
+
+// Band shades the range between the lowest and highest values in each column,
+// with the mean drawn in full colour, showing how much a reading varies.
+func Band(c *gfx.Canvas, r image.Rectangle, values []float64, opts Options) {
+	cols, lo, hi, ok := prepare(c, r, values, opts)
+	if !ok {
+		return
+	}
+	ramp := opts.ramp()
+
+	for i, col := range cols {
+		x := columnX(r, len(cols), i)
+		cmin, cmax := colRange(col)
+		top, bottom := rowY(r, position(cmax, lo, hi)), rowY(r, position(cmin, lo, hi))
+		t := position(mean(col), lo, hi)
+
+		shade := ramp(t)
+		shade = color.RGBA{shade.R / 3, shade.G / 3, shade.B / 3, 255}
+		c.FillRect(image.Rect(x, int(math.Floor(top)), x+1, int(math.Floor(bottom))+1), shade)
+		c.Set(x, int(math.Floor(rowY(r, t))), ramp(t))
+	}
+}
+
+// Gauge draws a horizontal bar filled in proportion to where the value lies in the
+// scale, which must be set. The unfilled part is drawn in the background colour.
+func Gauge(c *gfx.Canvas, r image.Rectangle, value float64, opts Options) {
+	lo, hi := opts.Scale.Min, opts.Scale.Max
+	if hi <= lo || r.Empty() {
+		return
+	}
+	if opts.Background.A > 0 {
+		c.FillRect(r, opts.Background)
+	}
+
+	t := position(value, lo, hi)
+	filled := r.Min.X + int(math.Round(t*float64(r.Dx())))
+	c.FillRect(image.Rect(r.Min.X, r.Min.Y, filled, r.Max.Y), opts.ramp()(t))
+}
+
+func (o Options) ramp() Ramp {
+	if o.Ramp == nil {
+		return Solid(color.RGBA{255, 255, 255, 255})
+	}
+	return o.Ramp
+}
+
+// prepare fills the background, splits the values into columns and works out the
+// scale. It reports false if there is nothing to draw.
+func prepare(c *gfx.Canvas, r image.Rectangle, values []float64, opts Options) ([][]float64, float64, float64, bool) {
+	if r.Empty() {
+		return nil, 0, 0, false
+	}
+	if opts.Background.A > 0 {
+		c.FillRect(r, opts.Background)
+	}
+	if len(values) == 0 {
+		return nil, 0, 0, false
+	}
+
+	lo, hi := opts.Scale.Min, opts.Scale.Max
+	if lo == hi {
+		lo, hi = autoScale(values, opts.Scale.IncludeZero)
+	}
+
+	return columns(values, r.Dx()), lo, hi, true
+}
+
+func autoScale(values []float64, includeZero bool) (float64, float64) {
+	lo, hi := values[0], values[0]
+	for _, v := range values[1:] {
+		lo, hi = math.Min(lo, v), math.Max(hi, v)
+	}
+	if includeZero {
+		lo, hi = math.Min(lo, 0), math.Max(hi, 0)
+	}
+	if lo == hi {
+		// a flat line sits in the middle of the chart
+		lo, hi = lo-1, hi+1
+	}
+	return lo, hi
+}
+
+// columns splits the values into at most n groups of neighbouring values, one per
+// column of pixels.
+func columns(values []float64, n int) [][]float64 {
+	if len(values) <= n {
+		cols := make([][]float64, len(values))
+		for i := range values {
+			cols[i] = values[i : i+1]
+		}
+		return cols
+	}
+
+	cols := make([][]float64, n)
+	for i := range cols {
+		cols[i] = values[i*len(values)/n : (i+1)*len(values)/n]
+	}
+	return cols
+}
+
+// columnX returns the x coordinate of a column, with the last column on the right
+// edge of the chart.
+func columnX(r image.Rectangle, count, i int) int {
+	return r.Max.X - count + i
+}
+
+// rowY returns the y coordinate of the middle of the pixel row for a position in
+// the scale.
+func rowY(r image.Rectangle, t float64) float64 {
+	return float64(r.Max.Y-1) - math.Round(t*float64(r.Dy()-1)) + 0.5
+}
+
+func position(v, lo, hi float64) float64 {
+	return clamp((v - lo) / (hi - lo))
+}
+
+func mean(values []float64) float64 {
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+func colRange(values []float64) (float64, float64) {
+	lo, hi := values[0], values[0]
+	for _, v := range values[1:] {
+		lo, hi = math.Min(lo, v), math.Max(hi, v)
+	}
+	return lo, hi
+}
+
+func clamp(t float64) float64 {
+	return math.Max(0, math.Min(1, t))
+}
+
+func mix(a, b color.RGBA, t float64) color.RGBA {
+	f := func(x, y uint8) uint8 {
+		return uint8(float64(x) + (float64(y)-float64(x))*t + 0.5)
+	}
+	return color.RGBA{f(a.R, b.R), f(a.G, b.G), f(a.B, b.B), f(a.A, b.A)}
+}
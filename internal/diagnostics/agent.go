@@ -7,6 +7,8 @@ import (
 	"net"
 	"sync"
 	"time"
+
+	"github.com/g-wilson/led/internal/history"
 )
 
 const (
@@ -70,10 +72,12 @@ type Agent struct {
 	lastPing      time.Duration
 	lastPingOk    bool
 	lastCheckedAt time.Time
+
+	pings *history.Series // ping times in milliseconds, only for successful pings
 }
 
 func New(ctx context.Context) (*Agent, error) {
-	a := &Agent{}
+	a := &Agent{pings: history.New(history.Capacity(pingInterval))}
 	a.checkOnce(ctx)
 
 	go func() {
@@ -104,6 +108,11 @@ func (a *Agent) GetStatus() Status {
 	}
 }
 
+// GetPingHistory returns the recent successful ping times, in milliseconds.
+func (a *Agent) GetPingHistory() *history.Series {
+	return a.pings
+}
+
 func (a *Agent) checkOnce(ctx context.Context) {
 	start := time.Now()
 	dialer := net.Dialer{Timeout: pingTimeout}
@@ -125,4 +134,5 @@ func (a *Agent) checkOnce(ctx context.Context) {
 	a.lastPingOk = true
 	a.lastPing = elapsed
 	a.lastHealthyAt = a.lastCheckedAt
+	a.pings.Add(a.lastCheckedAt, float64(elapsed)/float64(time.Millisecond))
 }
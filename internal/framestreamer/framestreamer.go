@@ -29,41 +29,75 @@ type Renderer interface {
 	DrawFrame(target *image.RGBA) error
 }
 
+// FrametimeProvider may be implemented by a Renderer which needs a different frame rate
+// at different times, such as while animating. It is asked after every frame, and the
+// stream's ticker is adjusted to match. Returning zero selects the stream's base frametime.
+type FrametimeProvider interface {
+	FrametimeMs() int64
+}
+
+// Filter adjusts a rendered frame in place before it is sent, such as scaling its brightness.
+type Filter interface {
+	Filter(frame *image.RGBA)
+}
+
 // FrameStreamer will stream image frames, from a provided renderer, at provided intervals, over a channel.
 // It manages a triple buffer pool internally to avoid per-frame allocations.
 type FrameStreamer struct {
 	C chan *image.RGBA
 	E chan error
 
-	renderer Renderer
-	bounds   image.Rectangle
-	ticker   *time.Ticker
-	started  bool
-	done     chan struct{}
-	stopOnce sync.Once
+	renderer  Renderer
+	filters   []Filter
+	bounds    image.Rectangle
+	ticker    *time.Ticker
+	frametime int64 // base frametime in ms, from Params
+	running   int64 // frametime the renderer is currently drawn at
+	repeat    int64 // frametime frames are sent at between draws, 0 to only send drawn frames
+	nextDraw  time.Time
+	started   bool
+	done      chan struct{}
+	stopOnce  sync.Once
 
 	// Buffer pool - triple buffering for zero-allocation frame streaming
 	buffers [bufferCount]*image.RGBA
 	current int
+	// drawn holds the last frame drawn, for filtering again on repeats
+	drawn *image.RGBA
 }
 
 type Params struct {
 	Bounds      image.Rectangle
 	Renderer    Renderer
 	FrametimeMs int64
+	// Filters are applied in order to every frame after it is drawn.
+	Filters []Filter
+	// RepeatMs, if set, sends frames at least this often for filters which change
+	// from one frame to the next, such as temporal dithering. Between the frames the
+	// renderer draws, the last one is filtered and sent again, so repeats cost a copy
+	// and the filters rather than drawing the page.
+	RepeatMs int64
 }
 
 // New creates a FrameStreamer but does not start rendering or sending until Start is called.
 // It pre-allocates a triple buffer pool based on the provided bounds.
 func New(params Params) *FrameStreamer {
 	fs := &FrameStreamer{
-		C:        make(chan *image.RGBA),
-		E:        make(chan error),
-		done:     make(chan struct{}),
-		renderer: params.Renderer,
-		bounds:   params.Bounds,
-		ticker:   time.NewTicker(time.Duration(params.FrametimeMs) * time.Millisecond),
-		current:  0,
+		C:         make(chan *image.RGBA),
+		E:         make(chan error),
+		done:      make(chan struct{}),
+		renderer:  params.Renderer,
+		filters:   params.Filters,
+		bounds:    params.Bounds,
+		frametime: params.FrametimeMs,
+		running:   params.FrametimeMs,
+		repeat:    params.RepeatMs,
+		current:   0,
+	}
+	fs.ticker = time.NewTicker(fs.tickInterval())
+
+	if fs.repeat > 0 {
+		fs.drawn = image.NewRGBA(params.Bounds)
 	}
 
 	// Pre-allocate triple buffer pool
@@ -86,6 +120,9 @@ func (fs *FrameStreamer) Start() {
 	// Clear buffer to black before initial rendering
 	buf := fs.buffers[fs.current]
 	draw.Draw(buf, buf.Bounds(), &image.Uniform{color.Black}, image.Point{}, draw.Src)
+	if fs.drawn != nil {
+		draw.Draw(fs.drawn, fs.drawn.Bounds(), &image.Uniform{color.Black}, image.Point{}, draw.Src)
+	}
 
 	// Start() is the sole sender on fs.C and fs.E, so it is responsible for closing them.
 	defer close(fs.C)
@@ -98,20 +135,42 @@ func (fs *FrameStreamer) Start() {
 		select {
 		case <-fs.done:
 			return
-		case <-fs.ticker.C:
+		case now := <-fs.ticker.C:
 			// Rotate to next buffer
 			fs.current = (fs.current + 1) % bufferCount
 			buf := fs.buffers[fs.current]
 
-			// Renderer draws into the provided buffer
-			// Note that we do not clear the image data in the buffer here
-			err := fs.renderer.DrawFrame(buf)
-			if err != nil {
-				select {
-				case fs.E <- err:
-				case <-fs.done:
+			// When repeating, frames are drawn into their own buffer and copied, so
+			// the last one drawn can be filtered again until the next is due
+			target := buf
+			if fs.drawn != nil {
+				target = fs.drawn
+			}
+
+			if fs.drawn == nil || !now.Before(fs.nextDraw) {
+				// Renderer draws into the provided buffer
+				// Note that we do not clear the image data in the buffer here
+				err := fs.renderer.DrawFrame(target)
+				if err != nil {
+					select {
+					case fs.E <- err:
+					case <-fs.done:
+					}
+					return
 				}
-				return
+
+				fs.adjustFrametime()
+
+				// allow for the ticks landing a little early
+				fs.nextDraw = now.Add(time.Duration(fs.running)*time.Millisecond - fs.tickInterval()/2)
+			}
+
+			if fs.drawn != nil {
+				copy(buf.Pix, fs.drawn.Pix)
+			}
+
+			for _, f := range fs.filters {
+				f.Filter(buf)
 			}
 
 			select {
@@ -123,6 +182,33 @@ func (fs *FrameStreamer) Start() {
 	}
 }
 
+// adjustFrametime resets the ticker if the renderer wants a different frame rate.
+func (fs *FrameStreamer) adjustFrametime() {
+	p, ok := fs.renderer.(FrametimeProvider)
+	if !ok {
+		return
+	}
+
+	want := p.FrametimeMs()
+	if want <= 0 {
+		want = fs.frametime
+	}
+	if want != fs.running {
+		fs.running = want
+		fs.ticker.Reset(fs.tickInterval())
+	}
+}
+
+// tickInterval is how often frames are sent: the frametime the renderer is drawn at,
+// or the repeat frametime if that is quicker.
+func (fs *FrameStreamer) tickInterval() time.Duration {
+	ms := fs.running
+	if fs.repeat > 0 && fs.repeat < ms {
+		ms = fs.repeat
+	}
+	return time.Duration(ms) * time.Millisecond
+}
+
 // Stop signals Start to exit and waits for channels to be closed.
 // Safe to call multiple times.
 func (fs *FrameStreamer) Stop() {
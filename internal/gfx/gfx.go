@@ -0,0 +1,277 @@
+// Package gfx draws lines, rectangles, circles, arcs and polygons onto an
+// *image.RGBA, anti-aliased or not, composited with alpha and clipped to a rectangle.
+//
+// Shapes are positioned in continuous coordinates where pixel (x, y) covers the
+// square from (x, y) to (x+1, y+1), so a circle centred on (16, 16) sits evenly
+// across the middle four pixels, and a one pixel wide line from (0, 0.5) to (8, 0.5)
+// exactly fills the top row of eight pixels.
+package gfx
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// subsamples is the number of samples taken across each side of a pixel when
+// estimating how much of it a filled area covers.
+const subsamples = 4
+
+// Canvas draws onto an image.
+type Canvas struct {
+	dst  *image.RGBA
+	clip image.Rectangle
+	// AntiAlias blends the edges of shapes into the pixels they partly cover. Without
+	// it, pixels are drawn solid if at least half covered, and left alone otherwise.
+	AntiAlias bool
+}
+
+// New creates an anti-aliasing canvas drawing onto dst, clipped to its bounds.
+func New(dst *image.RGBA) *Canvas {
+	return &Canvas{dst: dst, clip: dst.Bounds(), AntiAlias: true}
+}
+
+// Clip returns a copy of the canvas which only draws within r, as well as any clip
+// rectangle the canvas already has.
+func (c *Canvas) Clip(r image.Rectangle) *Canvas {
+	clipped := *c
+	clipped.clip = c.clip.Intersect(r)
+	return &clipped
+}
+
+// Bounds is the area the canvas draws within.
+func (c *Canvas) Bounds() image.Rectangle {
+	return c.clip
+}
+
+// Blend composites a colour over the pixel at (x, y), scaled by coverage from 0 to
+// 1. The colour's own alpha is respected, so translucent colours show what is beneath.
+func (c *Canvas) Blend(x, y int, col color.Color, coverage float64) {
+	if !image.Pt(x, y).In(c.clip) {
+		return
+	}
+	if !c.AntiAlias {
+		if coverage < 0.5 {
+			return
+		}
+		coverage = 1
+	}
+	if coverage <= 0 {
+		return
+	}
+	coverage = math.Min(coverage, 1)
+
+	// colours are premultiplied, so source-over is src + dst*(1-srcAlpha)
+	src := color.RGBAModel.Convert(col).(color.RGBA)
+	if src.A == 255 && coverage == 1 {
+		c.dst.SetRGBA(x, y, src)
+		return
+	}
+	dst := c.dst.RGBAAt(x, y)
+	keep := 1 - float64(src.A)/255*coverage
+	mix := func(s, d uint8) uint8 {
+		return uint8(math.Min(255, float64(s)*coverage+float64(d)*keep+0.5))
+	}
+	c.dst.SetRGBA(x, y, color.RGBA{mix(src.R, dst.R), mix(src.G, dst.G), mix(src.B, dst.B), mix(src.A, dst.A)})
+}
+
+// Set draws a single pixel.
+func (c *Canvas) Set(x, y int, col color.Color) {
+	c.Blend(x, y, col, 1)
+}
+
+// FillRect fills a rectangle of whole pixels.
+func (c *Canvas) FillRect(r image.Rectangle, col color.Color) {
+	r = r.Intersect(c.clip)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			c.Blend(x, y, col, 1)
+		}
+	}
+}
+
+// Rect draws the one pixel outline just inside a rectangle of whole pixels.
+func (c *Canvas) Rect(r image.Rectangle, col color.Color) {
+	if r.Empty() {
+		return
+	}
+	c.FillRect(image.Rect(r.Min.X, r.Min.Y, r.Max.X, r.Min.Y+1), col)
+	c.FillRect(image.Rect(r.Min.X, r.Max.Y-1, r.Max.X, r.Max.Y), col)
+	c.FillRect(image.Rect(r.Min.X, r.Min.Y+1, r.Min.X+1, r.Max.Y-1), col)
+	c.FillRect(image.Rect(r.Max.X-1, r.Min.Y+1, r.Max.X, r.Max.Y-1), col)
+}
+
+// Line draws a line of the given width between two points, with rounded ends.
+func (c *Canvas) Line(x0, y0, x1, y1, width float64, col color.Color) {
+	half := width / 2
+	pad := fringe(half)
+	area := bounds(math.Min(x0, x1)-pad, math.Min(y0, y1)-pad, math.Max(x0, x1)+pad, math.Max(y0, y1)+pad)
+	c.stroke(area, func(px, py float64) float64 {
+		return segmentDistance(px, py, x0, y0, x1, y1)
+	}, half, col)
+}
+
+// Circle draws the outline of a circle, with the stroke centred on the radius.
+func (c *Canvas) Circle(cx, cy, radius, width float64, col color.Color) {
+	half := width / 2
+	c.stroke(circleBounds(cx, cy, radius+fringe(half)), func(px, py float64) float64 {
+		return math.Abs(math.Hypot(px-cx, py-cy) - radius)
+	}, half, col)
+}
+
+// FillCircle draws a filled circle.
+func (c *Canvas) FillCircle(cx, cy, radius float64, col color.Color) {
+	c.stroke(circleBounds(cx, cy, radius+1), func(px, py float64) float64 {
+		return math.Hypot(px-cx, py-cy)
+	}, radius, col)
+}
+
+// Arc draws part of a circle's outline, with rounded ends. Angles are in degrees
+// clockwise from 12 o'clock, and the arc runs clockwise from start to end.
+func (c *Canvas) Arc(cx, cy, radius, width, start, end float64, col color.Color) {
+	half := width / 2
+	sweep := math.Mod(end-start, 360)
+	if sweep < 0 {
+		sweep += 360
+	}
+	if sweep == 0 && end != start {
+		sweep = 360
+	}
+	sx, sy := Polar(cx, cy, radius, start)
+	ex, ey := Polar(cx, cy, radius, start+sweep)
+
+	c.stroke(circleBounds(cx, cy, radius+fringe(half)), func(px, py float64) float64 {
+		angle := math.Mod(Angle(cx, cy, px, py)-start+360, 360)
+		if angle <= sweep {
+			return math.Abs(math.Hypot(px-cx, py-cy) - radius)
+		}
+		// beyond the ends, the distance to the nearest end gives a rounded cap
+		return math.Min(math.Hypot(px-sx, py-sy), math.Hypot(px-ex, py-ey))
+	}, half, col)
+}
+
+// Polygon draws the outline of a closed polygon through the points.
+func (c *Canvas) Polygon(points []Point, width float64, col color.Color) {
+	for i, p := range points {
+		q := points[(i+1)%len(points)]
+		c.Line(p.X, p.Y, q.X, q.Y, width, col)
+	}
+}
+
+// FillPolygon fills a polygon, using the even-odd rule for overlapping edges.
+func (c *Canvas) FillPolygon(points []Point, col color.Color) {
+	if len(points) < 3 {
+		return
+	}
+	minX, minY, maxX, maxY := points[0].X, points[0].Y, points[0].X, points[0].Y
+	for _, p := range points[1:] {
+		minX, minY = math.Min(minX, p.X), math.Min(minY, p.Y)
+		maxX, maxY = math.Max(maxX, p.X), math.Max(maxY, p.Y)
+	}
+	c.Fill(bounds(minX, minY, maxX, maxY), func(x, y float64) bool {
+		return insidePolygon(points, x, y)
+	}, col)
+}
+
+// Fill fills the parts of an area for which inside returns true, sampling each
+// pixel several times to anti-alias the edges. It suits shapes without a simple
+// outline, such as the lit part of the moon.
+func (c *Canvas) Fill(area image.Rectangle, inside func(x, y float64) bool, col color.Color) {
+	area = area.Intersect(c.clip)
+	samples := 1
+	if c.AntiAlias {
+		samples = subsamples
+	}
+	step := 1 / float64(samples)
+
+	for y := area.Min.Y; y < area.Max.Y; y++ {
+		for x := area.Min.X; x < area.Max.X; x++ {
+			hits := 0
+			for sy := 0; sy < samples; sy++ {
+				for sx := 0; sx < samples; sx++ {
+					if inside(float64(x)+(float64(sx)+0.5)*step, float64(y)+(float64(sy)+0.5)*step) {
+						hits++
+					}
+				}
+			}
+			if hits > 0 {
+				c.Blend(x, y, col, float64(hits)/float64(samples*samples))
+			}
+		}
+	}
+}
+
+// stroke blends the colour into each pixel of the area, by how much of the pixel
+// lies within half of the shape's middle, given the distance from the pixel
+// centre to the middle.
+func (c *Canvas) stroke(area image.Rectangle, distance func(px, py float64) float64, half float64, col color.Color) {
+	area = area.Intersect(c.clip)
+	for y := area.Min.Y; y < area.Max.Y; y++ {
+		for x := area.Min.X; x < area.Max.X; x++ {
+			d := distance(float64(x)+0.5, float64(y)+0.5)
+			c.Blend(x, y, col, math.Max(0, math.Min(1, half+0.5-d)))
+		}
+	}
+}
+
+// Point is a position in continuous canvas coordinates.
+type Point struct {
+	X, Y float64
+}
+
+// Pt is shorthand for Point{x, y}.
+func Pt(x, y float64) Point {
+	return Point{x, y}
+}
+
+// Polar returns the point at a distance from the centre, at an angle in degrees
+// clockwise from 12 o'clock.
+func Polar(cx, cy, distance, angle float64) (float64, float64) {
+	rad := angle * math.Pi / 180
+	return cx + distance*math.Sin(rad), cy - distance*math.Cos(rad)
+}
+
+// Angle returns the angle of a point from the centre, in degrees clockwise from
+// 12 o'clock, from 0 up to 360.
+func Angle(cx, cy, x, y float64) float64 {
+	a := math.Atan2(x-cx, cy-y) * 180 / math.Pi
+	if a < 0 {
+		a += 360
+	}
+	return a
+}
+
+func segmentDistance(px, py, x0, y0, x1, y1 float64) float64 {
+	dx, dy := x1-x0, y1-y0
+	t := 0.0
+	if length2 := dx*dx + dy*dy; length2 > 0 {
+		t = math.Max(0, math.Min(1, ((px-x0)*dx+(py-y0)*dy)/length2))
+	}
+	return math.Hypot(px-(x0+t*dx), py-(y0+t*dy))
+}
+
+func insidePolygon(points []Point, x, y float64) bool {
+	inside := false
+	for i, j := 0, len(points)-1; i < len(points); j, i = i, i+1 {
+		a, b := points[i], points[j]
+		if (a.Y > y) != (b.Y > y) && x < (b.X-a.X)*(y-a.Y)/(b.Y-a.Y)+a.X {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// bounds returns the pixels touched by an area in continuous coordinates.
+func bounds(minX, minY, maxX, maxY float64) image.Rectangle {
+	return image.Rect(int(math.Floor(minX)), int(math.Floor(minY)), int(math.Ceil(maxX)), int(math.Ceil(maxY)))
+}
+
+// fringe is how far beyond its middle a stroke half as wide as given can touch
+// pixels, taking in the anti-aliased edge, which reaches half a pixel further.
+func fringe(half float64) float64 {
+	return math.Ceil(half) + 1
+}
+
+func circleBounds(cx, cy, radius float64) image.Rectangle {
+	return bounds(cx-radius, cy-radius, cx+radius, cy+radius)
+}
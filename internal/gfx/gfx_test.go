@@ -0,0 +1,58 @@
+package gfx
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+// TestStrokeBounds checks shapes light every pixel their anti-aliased edges reach,
+// by drawing them again over the whole canvas, without a bounding box.
+func TestStrokeBounds(t *testing.T) {
+	white := color.RGBA{255, 255, 255, 255}
+	for _, width := range []float64{0.5, 1, 1.5, 2, 3.25} {
+		for _, off := range []float64{0, 0.25, 0.5, 0.75} {
+			x0, y0, x1, y1 := 4+off, 5-off, 20.5-off, 13+off
+			cx, cy, radius := 12+off, 12-off, 6.5+off
+
+			for _, tc := range []struct {
+				name     string
+				draw     func(c *Canvas)
+				distance func(px, py float64) float64
+				half     float64
+			}{
+				{
+					name:     "line",
+					draw:     func(c *Canvas) { c.Line(x0, y0, x1, y1, width, white) },
+					distance: func(px, py float64) float64 { return segmentDistance(px, py, x0, y0, x1, y1) },
+					half:     width / 2,
+				},
+				{
+					name:     "circle",
+					draw:     func(c *Canvas) { c.Circle(cx, cy, radius, width, white) },
+					distance: func(px, py float64) float64 { return math.Abs(math.Hypot(px-cx, py-cy) - radius) },
+					half:     width / 2,
+				},
+				{
+					name:     "filled circle",
+					draw:     func(c *Canvas) { c.FillCircle(cx, cy, radius, white) },
+					distance: func(px, py float64) float64 { return math.Hypot(px-cx, py-cy) },
+					half:     radius,
+				},
+			} {
+				got := image.NewRGBA(image.Rect(0, 0, 32, 32))
+				tc.draw(New(got))
+
+				want := image.NewRGBA(got.Rect)
+				c := New(want)
+				c.stroke(want.Rect, tc.distance, tc.half, white)
+
+				if !bytes.Equal(got.Pix, want.Pix) {
+					t.Errorf("%s %g wide, offset %g: pixels at the edge were left out", tc.name, width, off)
+				}
+			}
+		}
+	}
+}
@@ -119,6 +119,21 @@ func (a *Agent) GetPlayingPlayer() (MediaPlayerState, bool) {
 	return MediaPlayerState{}, false
 }
 
+// GetAllPlayers returns the cached state of every configured entity, in the order
+// they were provided to New. Entities which have not been fetched yet are left out.
+func (a *Agent) GetAllPlayers() []MediaPlayerState {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	out := make([]MediaPlayerState, 0, len(a.entityIDs))
+	for _, id := range a.entityIDs {
+		if p, ok := a.players[id]; ok {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
 func toDomain(entityID string, resp homeassistant.StateResponse) MediaPlayerState {
 	strAttr := func(key string) string {
 		v, _ := resp.Attributes[key].(string)
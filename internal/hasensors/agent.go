@@ -4,10 +4,12 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/g-wilson/led/internal/history"
 	"github.com/g-wilson/led/internal/homeassistant"
 )
 
@@ -55,6 +57,7 @@ type Agent struct {
 	mu      sync.RWMutex
 	sensors map[string]SensorState
 	areas   []homeassistant.AreaSensorsResponse
+	history map[string]*history.Series // numeric sensor states, by entity ID
 }
 
 func New(ctx context.Context, client StateProvider, entityIDs []string) (*Agent, error) {
@@ -67,6 +70,7 @@ func New(ctx context.Context, client StateProvider, entityIDs []string) (*Agent,
 		client:    client,
 		entityIDs: entityIDs,
 		sensors:   make(map[string]SensorState),
+		history:   make(map[string]*history.Series),
 	}
 
 	a.fetchAreas()
@@ -101,8 +105,16 @@ func (a *Agent) populateCache() {
 			continue
 		}
 
+		s := toDomain(entityID, resp)
+
 		a.mu.Lock()
-		a.sensors[entityID] = toDomain(entityID, resp)
+		a.sensors[entityID] = s
+		if value, err := strconv.ParseFloat(s.State, 64); err == nil {
+			if a.history[entityID] == nil {
+				a.history[entityID] = history.New(history.Capacity(refreshInterval))
+			}
+			a.history[entityID].Add(time.Now(), value)
+		}
 		a.mu.Unlock()
 	}
 }
@@ -115,6 +127,16 @@ func (a *Agent) GetSensor(entityID string) (SensorState, bool) {
 	return s, ok
 }
 
+// GetSensorHistory returns the readings of a sensor with a numeric state, if it
+// has reported any.
+func (a *Agent) GetSensorHistory(entityID string) (*history.Series, bool) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	h, ok := a.history[entityID]
+	return h, ok
+}
+
 func (a *Agent) GetAllSensors() []SensorState {
 	a.mu.RLock()
 	defer a.mu.RUnlock()
@@ -0,0 +1,114 @@
+// Package history keeps recent readings from the agents, such as ping times and
+// sensor values, so pages can show how they have changed.
+package history
+
+import (
+	"sync"
+	"time"
+)
+
+// Period is how far back a series sized with Capacity goes.
+const Period = 24 * time.Hour
+
+// DefaultCapacity holds a day of readings taken every two minutes, for series
+// created without a capacity.
+const DefaultCapacity = 720
+
+// Capacity returns the capacity of a series holding Period of readings taken every
+// interval, first and last included.
+func Capacity(interval time.Duration) int {
+	if interval <= 0 {
+		return DefaultCapacity
+	}
+	return int((Period+interval-1)/interval) + 1
+}
+
+// Sample is a single reading.
+type Sample struct {
+	Time  time.Time `json:"time"`
+	Value float64   `json:"value"`
+}
+
+// Series is a fixed size ring buffer of samples, oldest first, safe for concurrent
+// use. Once full, each new sample replaces the oldest.
+type Series struct {
+	mu      sync.RWMutex
+	samples []Sample
+	next    int // index the next sample is written to
+	full    bool
+}
+
+// New creates a Series holding up to capacity samples.
+func New(capacity int) *Series {
+	if capacity <= 0 {
+		capacity = DefaultCapacity
+	}
+	return &Series{samples: make([]Sample, capacity)}
+}
+
+// Add records a reading.
+func (s *Series) Add(t time.Time, value float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.samples[s.next] = Sample{Time: t, Value: value}
+	s.next = (s.next + 1) % len(s.samples)
+	if s.next == 0 {
+		s.full = true
+	}
+}
+
+// Len returns the number of samples held.
+func (s *Series) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.full {
+		return len(s.samples)
+	}
+	return s.next
+}
+
+// Samples returns a copy of the samples held, oldest first.
+func (s *Series) Samples() []Sample {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if !s.full {
+		return append([]Sample(nil), s.samples[:s.next]...)
+	}
+	out := make([]Sample, 0, len(s.samples))
+	out = append(out, s.samples[s.next:]...)
+	return append(out, s.samples[:s.next]...)
+}
+
+// Since returns the samples taken at or after t, oldest first.
+func (s *Series) Since(t time.Time) []Sample {
+	samples := s.Samples()
+	for i, sample := range samples {
+		if !sample.Time.Before(t) {
+			return samples[i:]
+		}
+	}
+	return nil
+}
+
+// Latest returns the most recent sample, if there is one.
+func (s *Series) Latest() (Sample, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if !s.full && s.next == 0 {
+		return Sample{}, false
+	}
+	return s.samples[(s.next-1+len(s.samples))%len(s.samples)], true
+}
+
+// Values returns the values of the samples, in the same order.
+func Values(samples []Sample) []float64 {
+	out := make([]float64, len(samples))
+	for i, s := range samples {
+		out[i] = s.Value
+	}
+	return out
+}
@@ -0,0 +1,25 @@
+package history
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCapacityHoldsPeriod(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for _, interval := range []time.Duration{time.Minute, 2 * time.Minute, 7 * time.Minute, time.Hour} {
+		s := New(Capacity(interval))
+
+		// readings for two periods, so the series has wrapped around
+		var now time.Time
+		for i := 0; i*int(interval) <= 2*int(Period); i++ {
+			now = start.Add(time.Duration(i) * interval)
+			s.Add(now, float64(i))
+		}
+
+		if oldest := s.Samples()[0].Time; now.Sub(oldest) < Period {
+			t.Errorf("every %v: oldest sample is %v before the latest, want at least %v", interval, now.Sub(oldest), Period)
+		}
+	}
+}
@@ -0,0 +1,246 @@
+// Package httpapi serves a small JSON API on the local network for controlling the
+// running clock: switching pages, pausing the rotation, setting the brightness and
+// theme, pushing messages and reading the data each agent has cached.
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/g-wilson/led/clock"
+)
+
+const shutdownTimeout = 5 * time.Second
+
+// Clock is the part of the clock the API controls.
+type Clock interface {
+	Pages() []clock.PageStatus
+	ShowPage(page string) error
+	PauseRotation()
+	ResumeRotation()
+	RotationPaused() bool
+	Brightness() (level int, overridden bool)
+	SetBrightness(level int) error
+	ClearBrightness()
+	Theme() (name string, overridden bool)
+	Themes() []string
+	SetTheme(name string) error
+	ClearTheme()
+	Notify(n clock.Notification) error
+	DismissNotification(key string)
+	Agents() []string
+	AgentData(name string) (any, bool)
+}
+
+type Server struct {
+	clock  Clock
+	server *http.Server
+}
+
+// New creates a Server listening on addr, such as ":8080", once started.
+func New(addr string, c Clock) *Server {
+	s := &Server{clock: c}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /pages", s.listPages)
+	mux.HandleFunc("POST /pages/{page}/show", s.showPage)
+	mux.HandleFunc("GET /rotation", s.getRotation)
+	mux.HandleFunc("POST /rotation/pause", s.pauseRotation)
+	mux.HandleFunc("POST /rotation/resume", s.resumeRotation)
+	mux.HandleFunc("GET /brightness", s.getBrightness)
+	mux.HandleFunc("PUT /brightness", s.setBrightness)
+	mux.HandleFunc("DELETE /brightness", s.clearBrightness)
+	mux.HandleFunc("GET /themes", s.listThemes)
+	mux.HandleFunc("GET /theme", s.getTheme)
+	mux.HandleFunc("PUT /theme", s.setTheme)
+	mux.HandleFunc("DELETE /theme", s.clearTheme)
+	mux.HandleFunc("POST /messages", s.postMessage)
+	mux.HandleFunc("DELETE /messages/{key}", s.dismissMessage)
+	mux.HandleFunc("GET /agents", s.listAgents)
+	mux.HandleFunc("GET /agents/{name}", s.getAgent)
+
+	s.server = &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	return s
+}
+
+// Start serves the API until the context is cancelled, then shuts the server down.
+func (s *Server) Start(ctx context.Context) error {
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		_ = s.server.Shutdown(shutdownCtx)
+	}()
+
+	log.Printf("http api listening on %s", s.server.Addr)
+
+	err := s.server.ListenAndServe()
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return fmt.Errorf("http api: %w", err)
+}
+
+func (s *Server) listPages(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.clock.Pages())
+}
+
+func (s *Server) showPage(w http.ResponseWriter, r *http.Request) {
+	if err := s.clock.ShowPage(r.PathValue("page")); err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type rotationResponse struct {
+	Paused  bool              `json:"paused"`
+	Current *clock.PageStatus `json:"current,omitempty"`
+}
+
+func (s *Server) getRotation(w http.ResponseWriter, r *http.Request) {
+	resp := rotationResponse{Paused: s.clock.RotationPaused()}
+	for _, p := range s.clock.Pages() {
+		if p.Current {
+			resp.Current = &p
+			break
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) pauseRotation(w http.ResponseWriter, r *http.Request) {
+	s.clock.PauseRotation()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) resumeRotation(w http.ResponseWriter, r *http.Request) {
+	s.clock.ResumeRotation()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type brightnessBody struct {
+	Level      int  `json:"level"`
+	Overridden bool `json:"overridden"`
+}
+
+func (s *Server) getBrightness(w http.ResponseWriter, r *http.Request) {
+	level, overridden := s.clock.Brightness()
+	writeJSON(w, http.StatusOK, brightnessBody{Level: level, Overridden: overridden})
+}
+
+func (s *Server) setBrightness(w http.ResponseWriter, r *http.Request) {
+	// a pointer, so a missing level isn't taken as turning the display off
+	var body struct {
+		Level *int `json:"level"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid body: %w", err))
+		return
+	}
+	if body.Level == nil {
+		writeError(w, http.StatusBadRequest, errors.New("level is required"))
+		return
+	}
+	if err := s.clock.SetBrightness(*body.Level); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	s.getBrightness(w, r)
+}
+
+func (s *Server) clearBrightness(w http.ResponseWriter, r *http.Request) {
+	s.clock.ClearBrightness()
+	s.getBrightness(w, r)
+}
+
+func (s *Server) listThemes(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.clock.Themes())
+}
+
+type themeBody struct {
+	Name       string `json:"name"`
+	Overridden bool   `json:"overridden"`
+}
+
+func (s *Server) getTheme(w http.ResponseWriter, r *http.Request) {
+	name, overridden := s.clock.Theme()
+	writeJSON(w, http.StatusOK, themeBody{Name: name, Overridden: overridden})
+}
+
+func (s *Server) setTheme(w http.ResponseWriter, r *http.Request) {
+	var body themeBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid body: %w", err))
+		return
+	}
+	if err := s.clock.SetTheme(body.Name); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	s.getTheme(w, r)
+}
+
+func (s *Server) clearTheme(w http.ResponseWriter, r *http.Request) {
+	s.clock.ClearTheme()
+	s.getTheme(w, r)
+}
+
+func (s *Server) postMessage(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid body: %w", err))
+		return
+	}
+	n, err := clock.DecodeNotification(data)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := s.clock.Notify(n); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, map[string]string{"key": n.Key})
+}
+
+func (s *Server) dismissMessage(w http.ResponseWriter, r *http.Request) {
+	s.clock.DismissNotification(r.PathValue("key"))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) listAgents(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.clock.Agents())
+}
+
+func (s *Server) getAgent(w http.ResponseWriter, r *http.Request) {
+	data, ok := s.clock.AgentData(r.PathValue("name"))
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no agent %q", r.PathValue("name")))
+		return
+	}
+	writeJSON(w, http.StatusOK, data)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("http api: error writing response: %v", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,57 @@
+package httpapi
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// fakeClock is a Clock with only its brightness implemented.
+type fakeClock struct {
+	Clock
+
+	level      int
+	overridden bool
+}
+
+func (c *fakeClock) Brightness() (int, bool) { return c.level, c.overridden }
+
+func (c *fakeClock) SetBrightness(level int) error {
+	if level < 0 || level > 100 {
+		return fmt.Errorf("invalid brightness %d", level)
+	}
+	c.level, c.overridden = level, true
+	return nil
+}
+
+var _ Clock = (*fakeClock)(nil)
+
+func TestSetBrightness(t *testing.T) {
+	for _, tc := range []struct {
+		body   string
+		status int
+		level  int
+	}{
+		{body: `{"level": 30}`, status: http.StatusOK, level: 30},
+		{body: `{"level": 0}`, status: http.StatusOK, level: 0},
+		{body: `{}`, status: http.StatusBadRequest, level: 60},
+		{body: `{"brightness": 30}`, status: http.StatusBadRequest, level: 60},
+		{body: `{"level": 101}`, status: http.StatusBadRequest, level: 60},
+		{body: `30`, status: http.StatusBadRequest, level: 60},
+	} {
+		c := &fakeClock{level: 60}
+		s := New(":0", c)
+
+		w := httptest.NewRecorder()
+		s.server.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/brightness", strings.NewReader(tc.body)))
+
+		if w.Code != tc.status {
+			t.Errorf("%s: status %d, want %d: %s", tc.body, w.Code, tc.status, w.Body)
+		}
+		if c.level != tc.level {
+			t.Errorf("%s: brightness %d, want %d", tc.body, c.level, tc.level)
+		}
+	}
+}
@@ -0,0 +1,386 @@
+// Package mqttbridge connects the clock to an MQTT broker. It takes messages, page
+// switches, brightness and theme commands from configurable topics, publishes the
+// current page, brightness, theme and agent health, and announces the clock to Home
+// Assistant through MQTT discovery as a device with a light and page and theme selects.
+package mqttbridge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"maps"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/g-wilson/led/clock"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+const (
+	pollInterval   = 1 * time.Second
+	healthInterval = 1 * time.Minute
+	publishTimeout = 5 * time.Second
+
+	payloadOnline  = "online"
+	payloadOffline = "offline"
+)
+
+// Clock is the part of the clock the bridge controls.
+type Clock interface {
+	Pages() []clock.PageStatus
+	CurrentPage() clock.PageStatus
+	ShowPage(page string) error
+	Brightness() (level int, overridden bool)
+	SetBrightness(level int) error
+	ClearBrightness()
+	Theme() (name string, overridden bool)
+	Themes() []string
+	SetTheme(name string) error
+	ClearTheme()
+	Notify(n clock.Notification) error
+	AgentHealth() map[string]bool
+}
+
+// Options configures the bridge. Only Broker is required.
+type Options struct {
+	Broker   string // e.g. tcp://192.168.1.100:1883
+	Username string
+	Password string
+	// ClientID identifies the clock to the broker, and to Home Assistant.
+	ClientID string
+	// TopicPrefix is prepended to the state topics, and to the command topics which
+	// are not set explicitly.
+	TopicPrefix string
+
+	// Command topics. MessageTopic takes a JSON message or plain text, PageTopic a
+	// page name, ID or index, BrightnessTopic a percentage, or "auto" to go back to
+	// following the brightness curve, and ThemeTopic a theme name, or "auto" to go
+	// back to the configured themes.
+	MessageTopic    string
+	PageTopic       string
+	BrightnessTopic string
+	ThemeTopic      string
+
+	// DiscoveryPrefix is Home Assistant's discovery topic prefix. Discovery is
+	// disabled if it is empty.
+	DiscoveryPrefix string
+}
+
+type Bridge struct {
+	clock   Clock
+	options Options
+	client  mqtt.Client
+
+	mu        sync.Mutex
+	offLevel  int  // brightness to restore when the light is turned back on
+	offManual bool // whether offLevel was set manually, rather than following the curve
+}
+
+// New creates a Bridge. It does not connect until Start is called.
+func New(options Options, c Clock) (*Bridge, error) {
+	if options.Broker == "" {
+		return nil, fmt.Errorf("mqtt broker is required")
+	}
+	if options.ClientID == "" {
+		options.ClientID = "led-clock"
+	}
+	if options.TopicPrefix == "" {
+		options.TopicPrefix = options.ClientID
+	}
+	options.TopicPrefix = strings.TrimSuffix(options.TopicPrefix, "/")
+	if options.MessageTopic == "" {
+		options.MessageTopic = options.TopicPrefix + "/message"
+	}
+	if options.PageTopic == "" {
+		options.PageTopic = options.TopicPrefix + "/page/set"
+	}
+	if options.BrightnessTopic == "" {
+		options.BrightnessTopic = options.TopicPrefix + "/brightness/set"
+	}
+	if options.ThemeTopic == "" {
+		options.ThemeTopic = options.TopicPrefix + "/theme/set"
+	}
+
+	b := &Bridge{clock: c, options: options}
+
+	opts := mqtt.NewClientOptions().
+		AddBroker(options.Broker).
+		SetClientID(options.ClientID).
+		SetUsername(options.Username).
+		SetPassword(options.Password).
+		SetAutoReconnect(true).
+		SetConnectRetry(true).
+		SetWill(b.topic("status"), payloadOffline, 1, true).
+		SetOnConnectHandler(b.onConnect).
+		SetConnectionLostHandler(func(_ mqtt.Client, err error) {
+			log.Printf("mqtt connection lost: %v", err)
+		})
+	b.client = mqtt.NewClient(opts)
+
+	return b, nil
+}
+
+func (b *Bridge) topic(name string) string {
+	return b.options.TopicPrefix + "/" + name
+}
+
+// Start connects to the broker and publishes state until the context is cancelled,
+// then marks the clock offline and disconnects. Connection failures are retried in
+// the background.
+func (b *Bridge) Start(ctx context.Context) {
+	log.Printf("connecting to mqtt broker %s", b.options.Broker)
+	b.client.Connect()
+
+	poll := time.NewTicker(pollInterval)
+	defer poll.Stop()
+	health := time.NewTicker(healthInterval)
+	defer health.Stop()
+
+	var lastPage, lastTheme string
+	lastLevel, lastOverridden := -1, false
+
+	for {
+		select {
+		case <-ctx.Done():
+			if b.client.IsConnected() {
+				b.publish("status", payloadOffline)
+			}
+			b.client.Disconnect(250)
+			return
+
+		case <-poll.C:
+			if !b.client.IsConnected() {
+				continue
+			}
+			if page := b.clock.CurrentPage().Name; page != lastPage {
+				b.publish("page", page)
+				lastPage = page
+			}
+			if level, overridden := b.clock.Brightness(); level != lastLevel || overridden != lastOverridden {
+				b.publishBrightness(level, overridden)
+				lastLevel, lastOverridden = level, overridden
+			}
+			if theme, _ := b.clock.Theme(); theme != lastTheme {
+				b.publish("theme", theme)
+				lastTheme = theme
+			}
+
+		case <-health.C:
+			if b.client.IsConnected() {
+				b.publishHealth()
+			}
+		}
+	}
+}
+
+// onConnect subscribes to the command topics and publishes everything from scratch,
+// since a reconnect may follow a broker restart.
+func (b *Bridge) onConnect(client mqtt.Client) {
+	log.Println("connected to mqtt broker")
+
+	subscriptions := map[string]mqtt.MessageHandler{
+		b.options.MessageTopic:    b.handleMessage,
+		b.options.PageTopic:       b.handlePage,
+		b.options.BrightnessTopic: b.handleBrightness,
+		b.options.ThemeTopic:      b.handleTheme,
+		b.topic("light/set"):      b.handleLight,
+	}
+	for topic, handler := range subscriptions {
+		if t := client.Subscribe(topic, 1, handler); t.WaitTimeout(publishTimeout) && t.Error() != nil {
+			log.Printf("mqtt: error subscribing to %s: %v", topic, t.Error())
+		}
+	}
+
+	if b.options.DiscoveryPrefix != "" {
+		b.publishDiscovery()
+	}
+	b.publish("status", payloadOnline)
+	b.publish("page", b.clock.CurrentPage().Name)
+	b.publishBrightness(b.clock.Brightness())
+	theme, _ := b.clock.Theme()
+	b.publish("theme", theme)
+	b.publishHealth()
+}
+
+func (b *Bridge) handleMessage(_ mqtt.Client, msg mqtt.Message) {
+	n, err := clock.DecodeNotification(msg.Payload())
+	if err == nil {
+		err = b.clock.Notify(n)
+	}
+	if err != nil {
+		log.Printf("mqtt: ignoring message on %s: %v", msg.Topic(), err)
+	}
+}
+
+func (b *Bridge) handlePage(_ mqtt.Client, msg mqtt.Message) {
+	if err := b.clock.ShowPage(strings.TrimSpace(string(msg.Payload()))); err != nil {
+		log.Printf("mqtt: ignoring page command: %v", err)
+	}
+}
+
+func (b *Bridge) handleBrightness(_ mqtt.Client, msg mqtt.Message) {
+	payload := strings.TrimSpace(string(msg.Payload()))
+	if strings.EqualFold(payload, "auto") {
+		b.clock.ClearBrightness()
+		return
+	}
+
+	level, err := strconv.Atoi(payload)
+	if err == nil {
+		err = b.clock.SetBrightness(level)
+	}
+	if err != nil {
+		log.Printf("mqtt: ignoring brightness command %q: %v", payload, err)
+	}
+}
+
+func (b *Bridge) handleTheme(_ mqtt.Client, msg mqtt.Message) {
+	payload := strings.TrimSpace(string(msg.Payload()))
+	if strings.EqualFold(payload, "auto") {
+		b.clock.ClearTheme()
+		return
+	}
+
+	if err := b.clock.SetTheme(payload); err != nil {
+		log.Printf("mqtt: ignoring theme command: %v", err)
+	}
+}
+
+// lightCommand is Home Assistant's JSON light schema, with brightness as a percentage.
+type lightCommand struct {
+	State      string `json:"state"`
+	Brightness *int   `json:"brightness,omitempty"`
+}
+
+// handleLight turns the display off by holding the brightness at zero, and back on
+// by restoring whatever the brightness was before.
+func (b *Bridge) handleLight(_ mqtt.Client, msg mqtt.Message) {
+	var cmd lightCommand
+	if err := json.Unmarshal(msg.Payload(), &cmd); err != nil {
+		log.Printf("mqtt: ignoring light command: %v", err)
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	level, overridden := b.clock.Brightness()
+	isOff := overridden && level == 0
+
+	var err error
+	switch {
+	case strings.EqualFold(cmd.State, "OFF"):
+		if !isOff {
+			b.offLevel, b.offManual = level, overridden
+		}
+		err = b.clock.SetBrightness(0)
+	case cmd.Brightness != nil:
+		err = b.clock.SetBrightness(*cmd.Brightness)
+	case isOff && b.offManual:
+		err = b.clock.SetBrightness(b.offLevel)
+	case isOff:
+		b.clock.ClearBrightness()
+	}
+	if err != nil {
+		log.Printf("mqtt: ignoring light command: %v", err)
+		return
+	}
+
+	b.publishBrightness(b.clock.Brightness())
+}
+
+func (b *Bridge) publishBrightness(level int, overridden bool) {
+	b.publish("brightness", strconv.Itoa(level))
+
+	state := "ON"
+	if overridden && level == 0 {
+		state = "OFF"
+	}
+	b.publishJSON(b.topic("light"), lightCommand{State: state, Brightness: &level})
+}
+
+func (b *Bridge) publishHealth() {
+	b.publishJSON(b.topic("health"), b.clock.AgentHealth())
+}
+
+func (b *Bridge) publish(name, payload string) {
+	b.publishRaw(b.topic(name), payload)
+}
+
+func (b *Bridge) publishJSON(topic string, v any) {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		log.Printf("mqtt: error encoding %s: %v", topic, err)
+		return
+	}
+	b.publishRaw(topic, payload)
+}
+
+func (b *Bridge) publishRaw(topic string, payload any) {
+	t := b.client.Publish(topic, 1, true, payload)
+	go func() {
+		if t.WaitTimeout(publishTimeout) && t.Error() != nil {
+			log.Printf("mqtt: error publishing to %s: %v", topic, t.Error())
+		}
+	}()
+}
+
+// publishDiscovery announces the display light, and page and theme selects, to Home
+// Assistant.
+func (b *Bridge) publishDiscovery() {
+	id := b.options.ClientID
+	device := map[string]any{
+		"identifiers":  []string{id},
+		"name":         "LED Clock",
+		"manufacturer": "g-wilson",
+		"model":        "LED matrix smart clock",
+	}
+	common := map[string]any{
+		"device":                device,
+		"availability_topic":    b.topic("status"),
+		"payload_available":     payloadOnline,
+		"payload_not_available": payloadOffline,
+	}
+
+	light := map[string]any{
+		"name":             "Display",
+		"unique_id":        id + "_display",
+		"schema":           "json",
+		"command_topic":    b.topic("light/set"),
+		"state_topic":      b.topic("light"),
+		"brightness":       true,
+		"brightness_scale": 100,
+	}
+	maps.Copy(light, common)
+	b.publishJSON(fmt.Sprintf("%s/light/%s/display/config", b.options.DiscoveryPrefix, id), light)
+
+	var options []string
+	for _, p := range b.clock.Pages() {
+		options = append(options, p.Name)
+	}
+	page := map[string]any{
+		"name":          "Page",
+		"unique_id":     id + "_page",
+		"command_topic": b.options.PageTopic,
+		"state_topic":   b.topic("page"),
+		"options":       options,
+		"icon":          "mdi:view-carousel",
+	}
+	maps.Copy(page, common)
+	b.publishJSON(fmt.Sprintf("%s/select/%s/page/config", b.options.DiscoveryPrefix, id), page)
+
+	theme := map[string]any{
+		"name":          "Theme",
+		"unique_id":     id + "_theme",
+		"command_topic": b.options.ThemeTopic,
+		"state_topic":   b.topic("theme"),
+		"options":       b.clock.Themes(),
+		"icon":          "mdi:palette",
+	}
+	maps.Copy(theme, common)
+	b.publishJSON(fmt.Sprintf("%s/select/%s/theme/config", b.options.DiscoveryPrefix, id), theme)
+}
@@ -0,0 +1,434 @@
+package mqttbridge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/g-wilson/led/clock"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+// curveLevel is the brightness fakeClock follows when it isn't overridden.
+const curveLevel = 60
+
+type fakeClock struct {
+	mu       sync.Mutex
+	override int // -1 to follow the curve
+	theme    string
+	page     string
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{override: -1, theme: "default", page: "Today"}
+}
+
+func (c *fakeClock) Pages() []clock.PageStatus {
+	return []clock.PageStatus{{Name: "Today"}, {Name: "Moon"}}
+}
+
+func (c *fakeClock) CurrentPage() clock.PageStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return clock.PageStatus{Name: c.page}
+}
+
+func (c *fakeClock) ShowPage(page string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.page = page
+	return nil
+}
+
+func (c *fakeClock) Brightness() (int, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.override >= 0 {
+		return c.override, true
+	}
+	return curveLevel, false
+}
+
+func (c *fakeClock) SetBrightness(level int) error {
+	if level < 0 || level > 100 {
+		return fmt.Errorf("invalid brightness %d", level)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.override = level
+	return nil
+}
+
+func (c *fakeClock) ClearBrightness() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.override = -1
+}
+
+func (c *fakeClock) Theme() (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.theme, c.theme != "default"
+}
+
+func (c *fakeClock) Themes() []string { return []string{"default", "night"} }
+
+func (c *fakeClock) SetTheme(name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.theme = name
+	return nil
+}
+
+func (c *fakeClock) ClearTheme() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.theme = "default"
+}
+
+func (c *fakeClock) Notify(n clock.Notification) error { return nil }
+
+func (c *fakeClock) AgentHealth() map[string]bool { return map[string]bool{"weather": true} }
+
+// fakeClient records what is published, in place of a connection to a broker.
+type fakeClient struct {
+	mqtt.Client
+
+	mu        sync.Mutex
+	published map[string][]byte
+}
+
+func (c *fakeClient) Publish(topic string, qos byte, retained bool, payload any) mqtt.Token {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch p := payload.(type) {
+	case string:
+		c.published[topic] = []byte(p)
+	case []byte:
+		c.published[topic] = p
+	}
+	return doneToken{}
+}
+
+func (c *fakeClient) payload(topic string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	p, ok := c.published[topic]
+	return p, ok
+}
+
+type doneToken struct{}
+
+func (doneToken) Wait() bool                     { return true }
+func (doneToken) WaitTimeout(time.Duration) bool { return true }
+func (doneToken) Done() <-chan struct{} {
+	done := make(chan struct{})
+	close(done)
+	return done
+}
+func (doneToken) Error() error { return nil }
+
+type fakeMessage struct {
+	mqtt.Message
+	topic   string
+	payload string
+}
+
+func (m fakeMessage) Topic() string   { return m.topic }
+func (m fakeMessage) Payload() []byte { return []byte(m.payload) }
+
+func newTestBridge(t *testing.T, options Options) (*Bridge, *fakeClock, *fakeClient) {
+	t.Helper()
+
+	if options.Broker == "" {
+		options.Broker = "tcp://localhost:1883"
+	}
+	c := newFakeClock()
+	b, err := New(options, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &fakeClient{published: map[string][]byte{}}
+	b.client = client
+	return b, c, client
+}
+
+func (b *Bridge) sendLight(payload string) {
+	b.handleLight(nil, fakeMessage{topic: b.topic("light/set"), payload: payload})
+}
+
+func TestLight(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		manual     int // brightness set before the commands, -1 to follow the curve
+		commands   []string
+		level      int
+		overridden bool
+	}{
+		{
+			name:       "off",
+			manual:     -1,
+			commands:   []string{`{"state": "OFF"}`},
+			level:      0,
+			overridden: true,
+		},
+		{
+			name:     "off and on again follows the curve",
+			manual:   -1,
+			commands: []string{`{"state": "OFF"}`, `{"state": "ON"}`},
+			level:    curveLevel,
+		},
+		{
+			name:       "off and on again restores a manual level",
+			manual:     40,
+			commands:   []string{`{"state": "OFF"}`, `{"state": "ON"}`},
+			level:      40,
+			overridden: true,
+		},
+		{
+			name:       "turning off twice remembers the level from before the first",
+			manual:     40,
+			commands:   []string{`{"state": "OFF"}`, `{"state": "OFF"}`, `{"state": "ON"}`},
+			level:      40,
+			overridden: true,
+		},
+		{
+			name:       "on while on changes nothing",
+			manual:     -1,
+			commands:   []string{`{"state": "ON"}`},
+			level:      curveLevel,
+			overridden: false,
+		},
+		{
+			name:       "brightness",
+			manual:     -1,
+			commands:   []string{`{"state": "ON", "brightness": 25}`},
+			level:      25,
+			overridden: true,
+		},
+		{
+			name:       "brightness while off turns on at that level",
+			manual:     40,
+			commands:   []string{`{"state": "OFF"}`, `{"brightness": 25}`},
+			level:      25,
+			overridden: true,
+		},
+		{
+			name:       "invalid brightness is ignored",
+			manual:     40,
+			commands:   []string{`{"state": "ON", "brightness": 250}`},
+			level:      40,
+			overridden: true,
+		},
+		{
+			name:       "invalid JSON is ignored",
+			manual:     40,
+			commands:   []string{`OFF`},
+			level:      40,
+			overridden: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			b, c, _ := newTestBridge(t, Options{})
+			if tc.manual >= 0 {
+				c.SetBrightness(tc.manual)
+			}
+			for _, cmd := range tc.commands {
+				b.sendLight(cmd)
+			}
+			if level, overridden := c.Brightness(); level != tc.level || overridden != tc.overridden {
+				t.Errorf("brightness %d, overridden %v, want %d, %v", level, overridden, tc.level, tc.overridden)
+			}
+		})
+	}
+}
+
+func TestLightPublishesState(t *testing.T) {
+	b, _, client := newTestBridge(t, Options{TopicPrefix: "clock"})
+
+	for _, tc := range []struct {
+		command string
+		state   string
+		level   int
+	}{
+		{`{"state": "OFF"}`, "OFF", 0},
+		{`{"state": "ON"}`, "ON", curveLevel},
+	} {
+		b.sendLight(tc.command)
+
+		payload, ok := client.payload("clock/light")
+		if !ok {
+			t.Fatal("light state not published")
+		}
+		var got lightCommand
+		if err := json.Unmarshal(payload, &got); err != nil {
+			t.Fatal(err)
+		}
+		if got.State != tc.state || got.Brightness == nil || *got.Brightness != tc.level {
+			t.Errorf("after %s, published %s, want state %s and brightness %d", tc.command, payload, tc.state, tc.level)
+		}
+		if level, _ := client.payload("clock/brightness"); string(level) != fmt.Sprint(tc.level) {
+			t.Errorf("after %s, published brightness %s, want %d", tc.command, level, tc.level)
+		}
+	}
+}
+
+func TestDiscovery(t *testing.T) {
+	b, _, client := newTestBridge(t, Options{
+		ClientID:        "hall-clock",
+		TopicPrefix:     "hall",
+		ThemeTopic:      "themes/hall",
+		DiscoveryPrefix: "homeassistant",
+	})
+	b.publishDiscovery()
+
+	config := func(topic string) map[string]any {
+		t.Helper()
+		payload, ok := client.payload(topic)
+		if !ok {
+			t.Fatalf("nothing published to %s", topic)
+		}
+		var v map[string]any
+		if err := json.Unmarshal(payload, &v); err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+
+	for _, tc := range []struct {
+		topic string
+		want  map[string]any
+	}{
+		{
+			topic: "homeassistant/light/hall-clock/display/config",
+			want: map[string]any{
+				"unique_id":        "hall-clock_display",
+				"schema":           "json",
+				"command_topic":    "hall/light/set",
+				"state_topic":      "hall/light",
+				"brightness":       true,
+				"brightness_scale": float64(100),
+			},
+		},
+		{
+			topic: "homeassistant/select/hall-clock/page/config",
+			want: map[string]any{
+				"unique_id":     "hall-clock_page",
+				"command_topic": "hall/page/set",
+				"state_topic":   "hall/page",
+				"options":       []any{"Today", "Moon"},
+			},
+		},
+		{
+			topic: "homeassistant/select/hall-clock/theme/config",
+			want: map[string]any{
+				"unique_id":     "hall-clock_theme",
+				"command_topic": "themes/hall",
+				"state_topic":   "hall/theme",
+				"options":       []any{"default", "night"},
+			},
+		},
+	} {
+		got := config(tc.topic)
+		for key, want := range tc.want {
+			if g, _ := json.Marshal(got[key]); string(g) != mustJSON(want) {
+				t.Errorf("%s: %s is %s, want %s", tc.topic, key, g, mustJSON(want))
+			}
+		}
+		if got["availability_topic"] != "hall/status" {
+			t.Errorf("%s: availability_topic is %v, want hall/status", tc.topic, got["availability_topic"])
+		}
+		device, _ := got["device"].(map[string]any)
+		if ids := mustJSON(device["identifiers"]); ids != `["hall-clock"]` {
+			t.Errorf("%s: device identifiers are %s, want [\"hall-clock\"]", tc.topic, ids)
+		}
+	}
+}
+
+func mustJSON(v any) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return string(b)
+}
+
+// TestBroker runs the bridge against a real broker, set with MQTT_TEST_BROKER, such
+// as tcp://localhost:1883 for a local mosquitto.
+func TestBroker(t *testing.T) {
+	broker := os.Getenv("MQTT_TEST_BROKER")
+	if broker == "" {
+		t.Skip("MQTT_TEST_BROKER not set")
+	}
+
+	prefix := fmt.Sprintf("led-clock-test-%d", time.Now().UnixNano())
+	c := newFakeClock()
+	b, err := New(Options{Broker: broker, ClientID: prefix, TopicPrefix: prefix}, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		b.Start(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	client := mqtt.NewClient(mqtt.NewClientOptions().AddBroker(broker).SetClientID(prefix + "-test"))
+	if tok := client.Connect(); !tok.WaitTimeout(publishTimeout) || tok.Error() != nil {
+		t.Fatalf("connecting to %s: %v", broker, tok.Error())
+	}
+	defer client.Disconnect(250)
+
+	status := make(chan string, 10)
+	client.Subscribe(prefix+"/status", 1, func(_ mqtt.Client, msg mqtt.Message) {
+		status <- string(msg.Payload())
+	})
+	waitFor(t, "the bridge to come online", func() bool {
+		select {
+		case s := <-status:
+			return s == payloadOnline
+		default:
+			return false
+		}
+	})
+
+	client.Publish(prefix+"/brightness/set", 1, false, "35")
+	waitFor(t, "the brightness to be set", func() bool {
+		level, overridden := c.Brightness()
+		return level == 35 && overridden
+	})
+
+	client.Publish(prefix+"/light/set", 1, false, `{"state": "OFF"}`)
+	waitFor(t, "the light to turn off", func() bool {
+		level, _ := c.Brightness()
+		return level == 0
+	})
+
+	client.Publish(prefix+"/light/set", 1, false, `{"state": "ON"}`)
+	waitFor(t, "the light to turn back on", func() bool {
+		level, _ := c.Brightness()
+		return level == 35
+	})
+}
+
+func waitFor(t *testing.T, what string, ok func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for !ok() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
@@ -0,0 +1,165 @@
+// Package pixelmap maps the canvas pages are drawn on to the pixels of the panels
+// showing it, for panels mounted rotated or mirrored, and for chains of panels
+// folded into shapes other than a single row.
+package pixelmap
+
+import (
+	"fmt"
+	"image"
+	"strings"
+)
+
+// Layouts the panels of a chain can be arranged in.
+const (
+	// Chain lays the panels out in a single row, in the order they are chained.
+	Chain = "chain"
+	// U folds the chain in half: the first half runs left to right along the top,
+	// and the second half back along the bottom, its panels upside down so the
+	// cables between them stay short.
+	U = "u"
+	// Serpentine folds the chain back and forth into Rows rows, every other row
+	// running right to left with its panels upside down. U is serpentine in two rows.
+	Serpentine = "serpentine"
+)
+
+// Options describes the panels and how they are mounted.
+type Options struct {
+	// PanelWidth and PanelHeight are the size of one panel, in pixels.
+	PanelWidth  int
+	PanelHeight int
+	// Chain is the number of panels in each chain, and Parallel the number of
+	// chains, which are stacked one below the other.
+	Chain    int
+	Parallel int
+	// Layout arranges each chain's panels: Chain, U or Serpentine.
+	Layout string
+	// Rows is the number of rows a serpentine layout folds each chain into.
+	Rows int
+	// Rotate turns the picture clockwise on the panels, by 0, 90, 180 or 270 degrees.
+	Rotate int
+	// MirrorH and MirrorV flip the picture left to right and top to bottom, after
+	// rotating it.
+	MirrorH bool
+	MirrorV bool
+}
+
+// Mapper copies frames from the canvas pages draw on to the panels' pixels.
+type Mapper struct {
+	logical  image.Rectangle
+	physical image.Rectangle
+	offsets  []int // offset in the physical Pix of each logical pixel, in order
+	identity bool  // pixels map straight across
+}
+
+// New creates a Mapper, checking the panels fit together as described.
+func New(opts Options) (*Mapper, error) {
+	if opts.PanelWidth < 1 || opts.PanelHeight < 1 {
+		return nil, fmt.Errorf("invalid panel size %dx%d", opts.PanelWidth, opts.PanelHeight)
+	}
+	if opts.Chain < 1 || opts.Parallel < 1 {
+		return nil, fmt.Errorf("invalid chain of %d panels in %d parallel: expected at least 1 of each", opts.Chain, opts.Parallel)
+	}
+
+	rows := 1
+	switch strings.ToLower(opts.Layout) {
+	case Chain, "":
+	case U:
+		rows = 2
+	case Serpentine:
+		rows = opts.Rows
+		if rows < 1 {
+			return nil, fmt.Errorf("invalid serpentine layout of %d rows", rows)
+		}
+	default:
+		return nil, fmt.Errorf("unknown panel layout %q: expected %s, %s or %s", opts.Layout, Chain, U, Serpentine)
+	}
+	if opts.Chain%rows != 0 {
+		return nil, fmt.Errorf("cannot fold a chain of %d panels into %d rows", opts.Chain, rows)
+	}
+	switch opts.Rotate {
+	case 0, 90, 180, 270:
+	default:
+		return nil, fmt.Errorf("invalid rotation %d: expected 0, 90, 180 or 270", opts.Rotate)
+	}
+
+	pw, ph := opts.PanelWidth, opts.PanelHeight
+	perRow := opts.Chain / rows
+
+	// the panels as mounted, before rotating the picture onto them
+	arrangedWidth := perRow * pw
+	arrangedHeight := rows * ph * opts.Parallel
+
+	m := &Mapper{
+		logical:  image.Rect(0, 0, arrangedWidth, arrangedHeight),
+		physical: image.Rect(0, 0, opts.Chain*pw, opts.Parallel*ph),
+		identity: rows == 1 && opts.Rotate == 0 && !opts.MirrorH && !opts.MirrorV,
+	}
+	if opts.Rotate == 90 || opts.Rotate == 270 {
+		m.logical = image.Rect(0, 0, arrangedHeight, arrangedWidth)
+	}
+	if m.identity {
+		return m, nil
+	}
+
+	lw, lh := m.logical.Dx(), m.logical.Dy()
+	m.offsets = make([]int, 0, lw*lh)
+	for y := range lh {
+		for x := range lw {
+			// rotate and mirror onto the arrangement of panels
+			var ax, ay int
+			switch opts.Rotate {
+			case 0:
+				ax, ay = x, y
+			case 90:
+				ax, ay = lh-1-y, x
+			case 180:
+				ax, ay = lw-1-x, lh-1-y
+			case 270:
+				ax, ay = y, lw-1-x
+			}
+			if opts.MirrorH {
+				ax = arrangedWidth - 1 - ax
+			}
+			if opts.MirrorV {
+				ay = arrangedHeight - 1 - ay
+			}
+
+			// find the panel, and where it sits in its chain
+			band, within := ay/(rows*ph), ay%(rows*ph)
+			row, py := within/ph, within%ph
+			col, px := ax/pw, ax%pw
+			position := row*perRow + col
+			if row%2 == 1 {
+				position = row*perRow + perRow - 1 - col
+				px, py = pw-1-px, ph-1-py
+			}
+
+			m.offsets = append(m.offsets, m.physical.Dx()*(band*ph+py)*4+(position*pw+px)*4)
+		}
+	}
+
+	return m, nil
+}
+
+// Bounds returns the canvas pages should be drawn on.
+func (m *Mapper) Bounds() image.Rectangle {
+	return m.logical
+}
+
+// PhysicalBounds returns the canvas of the panels, as the matrix driver lays them
+// out one after another along each chain.
+func (m *Mapper) PhysicalBounds() image.Rectangle {
+	return m.physical
+}
+
+// Map copies a frame the size of Bounds to dst, the size of PhysicalBounds.
+func (m *Mapper) Map(dst, src *image.RGBA) {
+	if m.identity {
+		copy(dst.Pix, src.Pix)
+		return
+	}
+
+	for i, offset := range m.offsets {
+		copy(dst.Pix[offset:offset+4], src.Pix[i*4:i*4+4])
+	}
+}
@@ -0,0 +1,190 @@
+package pixelmap
+
+import (
+	"image"
+	"testing"
+)
+
+// indexed encodes the position of each pixel of a canvas in its colour, so where it
+// ends up can be traced.
+func indexed(r image.Rectangle) *image.RGBA {
+	img := image.NewRGBA(r)
+	for i := 0; i < r.Dx()*r.Dy(); i++ {
+		img.Pix[i*4], img.Pix[i*4+1], img.Pix[i*4+2], img.Pix[i*4+3] = uint8(i), uint8(i>>8), uint8(i>>16), 255
+	}
+	return img
+}
+
+func TestMap(t *testing.T) {
+	// two 64x32 panels, in one or two chains
+	panels := func(opts Options) Options {
+		opts.PanelWidth, opts.PanelHeight, opts.Chain = 64, 32, 2
+		if opts.Parallel == 0 {
+			opts.Parallel = 1
+		}
+		return opts
+	}
+
+	for _, tc := range []struct {
+		name     string
+		opts     Options
+		logical  image.Point
+		physical image.Point
+		// logical points and the physical pixels they should land on
+		points map[image.Point]image.Point
+	}{
+		{
+			name:     "chain",
+			opts:     panels(Options{}),
+			logical:  image.Pt(128, 32),
+			physical: image.Pt(128, 32),
+			points: map[image.Point]image.Point{
+				{0, 0}: {0, 0}, {64, 0}: {64, 0}, {127, 31}: {127, 31},
+			},
+		},
+		{
+			name:     "rotated 90",
+			opts:     panels(Options{Rotate: 90}),
+			logical:  image.Pt(32, 128),
+			physical: image.Pt(128, 32),
+			points: map[image.Point]image.Point{
+				{0, 0}: {127, 0}, {31, 0}: {127, 31}, {0, 127}: {0, 0}, {5, 10}: {117, 5},
+			},
+		},
+		{
+			name:     "rotated 180",
+			opts:     panels(Options{Rotate: 180}),
+			logical:  image.Pt(128, 32),
+			physical: image.Pt(128, 32),
+			points: map[image.Point]image.Point{
+				{0, 0}: {127, 31}, {127, 31}: {0, 0}, {5, 10}: {122, 21},
+			},
+		},
+		{
+			name:     "rotated 270",
+			opts:     panels(Options{Rotate: 270}),
+			logical:  image.Pt(32, 128),
+			physical: image.Pt(128, 32),
+			points: map[image.Point]image.Point{
+				{0, 0}: {0, 31}, {31, 0}: {0, 0}, {0, 127}: {127, 31}, {5, 10}: {10, 26},
+			},
+		},
+		{
+			name:     "mirrored left to right",
+			opts:     panels(Options{MirrorH: true}),
+			logical:  image.Pt(128, 32),
+			physical: image.Pt(128, 32),
+			points: map[image.Point]image.Point{
+				{0, 0}: {127, 0}, {5, 10}: {122, 10},
+			},
+		},
+		{
+			name:     "mirrored top to bottom",
+			opts:     panels(Options{MirrorV: true}),
+			logical:  image.Pt(128, 32),
+			physical: image.Pt(128, 32),
+			points: map[image.Point]image.Point{
+				{0, 0}: {0, 31}, {5, 10}: {5, 21},
+			},
+		},
+		{
+			name:     "rotated 90 and mirrored",
+			opts:     panels(Options{Rotate: 90, MirrorH: true}),
+			logical:  image.Pt(32, 128),
+			physical: image.Pt(128, 32),
+			points: map[image.Point]image.Point{
+				{0, 0}: {0, 0}, {5, 10}: {10, 5},
+			},
+		},
+		{
+			// the second panel is upside down below the first
+			name:     "u",
+			opts:     panels(Options{Layout: U}),
+			logical:  image.Pt(64, 64),
+			physical: image.Pt(128, 32),
+			points: map[image.Point]image.Point{
+				{0, 0}: {0, 0}, {63, 31}: {63, 31}, {0, 32}: {127, 31}, {63, 63}: {64, 0}, {10, 40}: {117, 23},
+			},
+		},
+		{
+			name:     "serpentine in two rows",
+			opts:     panels(Options{Layout: Serpentine, Rows: 2}),
+			logical:  image.Pt(64, 64),
+			physical: image.Pt(128, 32),
+			points: map[image.Point]image.Point{
+				{0, 0}: {0, 0}, {0, 32}: {127, 31}, {10, 40}: {117, 23},
+			},
+		},
+		{
+			name:     "u rotated 90",
+			opts:     panels(Options{Layout: U, Rotate: 90}),
+			logical:  image.Pt(64, 64),
+			physical: image.Pt(128, 32),
+			points: map[image.Point]image.Point{
+				{0, 0}: {63, 0}, {63, 0}: {64, 0}, {0, 63}: {0, 0},
+			},
+		},
+		{
+			// each chain is folded, and the second chain's panels go below the first's
+			name:     "u in two parallel chains",
+			opts:     panels(Options{Layout: U, Parallel: 2}),
+			logical:  image.Pt(64, 128),
+			physical: image.Pt(128, 64),
+			points: map[image.Point]image.Point{
+				{0, 0}: {0, 0}, {0, 32}: {127, 31}, {0, 64}: {0, 32}, {0, 96}: {127, 63},
+			},
+		},
+	} {
+		m, err := New(tc.opts)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if got := m.Bounds().Size(); got != tc.logical {
+			t.Errorf("%s: canvas is %v, want %v", tc.name, got, tc.logical)
+		}
+		if got := m.PhysicalBounds().Size(); got != tc.physical {
+			t.Errorf("%s: panels are %v, want %v", tc.name, got, tc.physical)
+		}
+
+		src := indexed(m.Bounds())
+		dst := image.NewRGBA(m.PhysicalBounds())
+		m.Map(dst, src)
+
+		// every pixel of the panels is drawn from exactly one pixel of the canvas
+		seen := make([]bool, len(src.Pix)/4)
+		for y := range dst.Rect.Dy() {
+			for x := range dst.Rect.Dx() {
+				p := dst.RGBAAt(x, y)
+				i := int(p.R) | int(p.G)<<8 | int(p.B)<<16
+				if p.A == 0 || i >= len(seen) || seen[i] {
+					t.Errorf("%s: physical pixel %d,%d is blank or drawn twice", tc.name, x, y)
+					continue
+				}
+				seen[i] = true
+			}
+		}
+
+		for from, to := range tc.points {
+			want := src.RGBAAt(from.X, from.Y)
+			if got := dst.RGBAAt(to.X, to.Y); got != want {
+				i := int(got.R) | int(got.G)<<8 | int(got.B)<<16
+				t.Errorf("%s: %v should be at %v, which has %v", tc.name, from, to, image.Pt(i%tc.logical.X, i/tc.logical.X))
+			}
+		}
+	}
+}
+
+func TestNewInvalid(t *testing.T) {
+	for _, opts := range []Options{
+		{PanelWidth: 64, PanelHeight: 0, Chain: 1, Parallel: 1},
+		{PanelWidth: 64, PanelHeight: 32, Chain: 0, Parallel: 1},
+		{PanelWidth: 64, PanelHeight: 32, Chain: 3, Parallel: 1, Layout: U},
+		{PanelWidth: 64, PanelHeight: 32, Chain: 2, Parallel: 1, Layout: Serpentine},
+		{PanelWidth: 64, PanelHeight: 32, Chain: 2, Parallel: 1, Layout: "zigzag"},
+		{PanelWidth: 64, PanelHeight: 32, Chain: 2, Parallel: 1, Rotate: 45},
+	} {
+		if _, err := New(opts); err == nil {
+			t.Errorf("%+v: no error", opts)
+		}
+	}
+}
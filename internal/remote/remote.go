@@ -0,0 +1,57 @@
+// Package remote starts the ways of controlling the clock from elsewhere on the
+// network, the HTTP API and the MQTT bridge, as configured.
+package remote
+
+import (
+	"context"
+	"log"
+
+	"github.com/g-wilson/led/config"
+	"github.com/g-wilson/led/internal/httpapi"
+	"github.com/g-wilson/led/internal/mqttbridge"
+)
+
+// Clock is the part of the clock the HTTP API and MQTT bridge control.
+type Clock interface {
+	httpapi.Clock
+	mqttbridge.Clock
+}
+
+// Start starts the HTTP API if HTTP_ADDR is set, and the MQTT bridge if MQTT_BROKER
+// is, both running until the context is cancelled. The API failing to serve, such
+// as when its address is in use, stops the program.
+func Start(ctx context.Context, cfg *config.Settings, c Clock) error {
+	if cfg.HTTPAddr != "" {
+		api := httpapi.New(cfg.HTTPAddr, c)
+		go func() {
+			if err := api.Start(ctx); err != nil {
+				log.Fatalln(err)
+			}
+		}()
+	}
+
+	if cfg.MQTTBroker != "" {
+		discoveryPrefix := cfg.MQTTDiscoveryPrefix
+		if !cfg.MQTTDiscovery {
+			discoveryPrefix = ""
+		}
+		bridge, err := mqttbridge.New(mqttbridge.Options{
+			Broker:          cfg.MQTTBroker,
+			Username:        cfg.MQTTUsername,
+			Password:        cfg.MQTTPassword,
+			ClientID:        cfg.MQTTClientID,
+			TopicPrefix:     cfg.MQTTTopicPrefix,
+			MessageTopic:    cfg.MQTTMessageTopic,
+			PageTopic:       cfg.MQTTPageTopic,
+			BrightnessTopic: cfg.MQTTBrightnessTopic,
+			ThemeTopic:      cfg.MQTTThemeTopic,
+			DiscoveryPrefix: discoveryPrefix,
+		}, c)
+		if err != nil {
+			return err
+		}
+		go bridge.Start(ctx)
+	}
+
+	return nil
+}
@@ -0,0 +1,148 @@
+// Package schedule resolves daily time windows, which may be fixed times of day or
+// relative to sunrise and sunset, and may differ at weekends.
+package schedule
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+type anchor int
+
+const (
+	anchorMidnight anchor = iota
+	anchorSunrise
+	anchorSunset
+)
+
+// TimeSpec is a time of day. It is either a fixed time ("22:30") or an offset
+// from sunrise or sunset ("sunset+30m", "sunrise-1h", "sunset").
+type TimeSpec struct {
+	anchor anchor
+	offset time.Duration
+}
+
+// ParseTimeSpec parses a fixed "15:04" time of day, or "sunrise" or "sunset"
+// optionally followed by a signed Go duration.
+func ParseTimeSpec(s string) (TimeSpec, error) {
+	s = strings.TrimSpace(strings.ToLower(s))
+
+	for name, a := range map[string]anchor{"sunrise": anchorSunrise, "sunset": anchorSunset} {
+		rest, ok := strings.CutPrefix(s, name)
+		if !ok {
+			continue
+		}
+		if rest == "" {
+			return TimeSpec{anchor: a}, nil
+		}
+		if rest[0] != '+' && rest[0] != '-' {
+			return TimeSpec{}, fmt.Errorf("invalid time %q: expected + or - after %s", s, name)
+		}
+		offset, err := time.ParseDuration(rest)
+		if err != nil {
+			return TimeSpec{}, fmt.Errorf("invalid time %q: %w", s, err)
+		}
+		return TimeSpec{anchor: a, offset: offset}, nil
+	}
+
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return TimeSpec{}, fmt.Errorf("invalid time %q: expected HH:MM, sunrise or sunset", s)
+	}
+	return TimeSpec{anchor: anchorMidnight, offset: time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute}, nil
+}
+
+// SunProvider gives the sunrise and sunset times for the day containing the given time.
+type SunProvider interface {
+	SunTimes(day time.Time) (sunrise, sunset time.Time)
+}
+
+// On resolves the time of day on the date of day, in day's location.
+func (t TimeSpec) On(day time.Time, sun SunProvider) time.Time {
+	year, month, date := day.Date()
+	midnight := time.Date(year, month, date, 0, 0, 0, 0, day.Location())
+
+	switch t.anchor {
+	case anchorSunrise:
+		sunrise, _ := sun.SunTimes(midnight)
+		return sunrise.Add(t.offset)
+	case anchorSunset:
+		_, sunset := sun.SunTimes(midnight)
+		return sunset.Add(t.offset)
+	default:
+		// built from the wall clock so that DST changes don't shift the time
+		return time.Date(year, month, date, int(t.offset/time.Hour), int(t.offset%time.Hour/time.Minute), 0, 0, day.Location())
+	}
+}
+
+// Window is a daily period between two times, which may cross midnight.
+type Window struct {
+	Start TimeSpec
+	End   TimeSpec
+}
+
+// bounds resolves the window starting on the date of day.
+func (w Window) bounds(day time.Time, sun SunProvider) (start, end time.Time) {
+	start = w.Start.On(day, sun)
+	end = w.End.On(day, sun)
+	if !end.After(start) {
+		end = w.End.On(day.AddDate(0, 0, 1), sun)
+	}
+	return start, end
+}
+
+// Schedule chooses a window for each day, with an optional different window for
+// periods starting on weekend days.
+type Schedule struct {
+	Weekday     Window
+	Weekend     *Window
+	WeekendDays []time.Weekday
+}
+
+// window returns the window which starts on the date of day.
+func (s Schedule) window(day time.Time) Window {
+	if s.Weekend != nil {
+		for _, wd := range s.WeekendDays {
+			if day.Weekday() == wd {
+				return *s.Weekend
+			}
+		}
+	}
+	return s.Weekday
+}
+
+// Contains reports whether t falls within the window which started on the same
+// day or the day before.
+func (s Schedule) Contains(t time.Time, sun SunProvider) bool {
+	for _, day := range []time.Time{t.AddDate(0, 0, -1), t} {
+		start, end := s.window(day).bounds(day, sun)
+		if !t.Before(start) && t.Before(end) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseWeekdays parses day names, which may be abbreviated to three letters ("fri", "sat").
+func ParseWeekdays(names []string) ([]time.Weekday, error) {
+	days := make([]time.Weekday, 0, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(strings.ToLower(name))
+		if name == "" {
+			continue
+		}
+		found := false
+		for d := time.Sunday; d <= time.Saturday; d++ {
+			if strings.HasPrefix(strings.ToLower(d.String()), name) && len(name) >= 3 {
+				days = append(days, d)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("invalid day %q", name)
+		}
+	}
+	return days, nil
+}
@@ -0,0 +1,158 @@
+// Package sprite loads small images, such as icons, from a sprite sheet: a PNG
+// holding many images, with a JSON index naming where each one is.
+//
+// The index looks like:
+//
+//	{
+//	  "sprites": [
+//	    {"name": "clear", "x": 0, "y": 0, "width": 12, "height": 12},
+//	    {"name": "rain", "x": 0, "y": 12, "width": 12, "height": 12, "frames": 4, "frameTime": "150ms"}
+//	  ]
+//	}
+//
+// A sprite with more than one frame is animated. Its frames are laid out left to
+// right from its position, each the sprite's width, and shown frameTime apart
+// before starting again.
+package sprite
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"sort"
+	"time"
+)
+
+// Sheet is a set of named sprites cut from one image.
+type Sheet struct {
+	sprites map[string]*Sprite
+}
+
+// Sprite is a single image, or an animation of several frames of the same size.
+type Sprite struct {
+	Name      string
+	frames    []*image.RGBA
+	frameTime time.Duration
+}
+
+type index struct {
+	Sprites []indexEntry `json:"sprites"`
+}
+
+type indexEntry struct {
+	Name      string `json:"name"`
+	X         int    `json:"x"`
+	Y         int    `json:"y"`
+	Width     int    `json:"width"`
+	Height    int    `json:"height"`
+	Frames    int    `json:"frames"`
+	FrameTime string `json:"frameTime"`
+}
+
+// Load cuts the sprites listed in the JSON index out of the PNG image.
+func Load(pngData, indexData []byte) (*Sheet, error) {
+	img, err := png.Decode(bytes.NewReader(pngData))
+	if err != nil {
+		return nil, fmt.Errorf("error decoding sprite sheet: %w", err)
+	}
+
+	var idx index
+	if err := json.Unmarshal(indexData, &idx); err != nil {
+		return nil, fmt.Errorf("error decoding sprite index: %w", err)
+	}
+
+	sheet := &Sheet{sprites: make(map[string]*Sprite, len(idx.Sprites))}
+	for _, e := range idx.Sprites {
+		s, err := e.cut(img)
+		if err != nil {
+			return nil, fmt.Errorf("sprite %q: %w", e.Name, err)
+		}
+		if _, ok := sheet.sprites[e.Name]; ok {
+			return nil, fmt.Errorf("sprite %q: listed more than once", e.Name)
+		}
+		sheet.sprites[e.Name] = s
+	}
+
+	return sheet, nil
+}
+
+func (e indexEntry) cut(img image.Image) (*Sprite, error) {
+	if e.Name == "" {
+		return nil, fmt.Errorf("missing name")
+	}
+	if e.Width <= 0 || e.Height <= 0 {
+		return nil, fmt.Errorf("invalid size %dx%d", e.Width, e.Height)
+	}
+
+	frames := max(e.Frames, 1)
+	s := &Sprite{Name: e.Name}
+	if frames > 1 {
+		d, err := time.ParseDuration(e.FrameTime)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid frame time %q", e.FrameTime)
+		}
+		s.frameTime = d
+	}
+
+	for i := 0; i < frames; i++ {
+		r := image.Rect(e.X+i*e.Width, e.Y, e.X+(i+1)*e.Width, e.Y+e.Height).Add(img.Bounds().Min)
+		if !r.In(img.Bounds()) {
+			return nil, fmt.Errorf("frame %d lies outside the sheet", i)
+		}
+		frame := image.NewRGBA(image.Rect(0, 0, e.Width, e.Height))
+		draw.Draw(frame, frame.Bounds(), img, r.Min, draw.Src)
+		s.frames = append(s.frames, frame)
+	}
+
+	return s, nil
+}
+
+// Get returns the named sprite.
+func (s *Sheet) Get(name string) (*Sprite, bool) {
+	sprite, ok := s.sprites[name]
+	return sprite, ok
+}
+
+// Names returns the names of the sprites in the sheet, sorted.
+func (s *Sheet) Names() []string {
+	names := make([]string, 0, len(s.sprites))
+	for name := range s.sprites {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Bounds is the size of the sprite, at the origin.
+func (s *Sprite) Bounds() image.Rectangle {
+	return s.frames[0].Bounds()
+}
+
+// Animated reports whether the sprite has more than one frame.
+func (s *Sprite) Animated() bool {
+	return len(s.frames) > 1
+}
+
+// FrameTime is how long each frame of an animated sprite is shown, or zero if it
+// is not animated.
+func (s *Sprite) FrameTime() time.Duration {
+	return s.frameTime
+}
+
+// Frame returns the frame to show once the animation has been running for elapsed.
+func (s *Sprite) Frame(elapsed time.Duration) *image.RGBA {
+	if !s.Animated() || elapsed < 0 {
+		return s.frames[0]
+	}
+	return s.frames[int(elapsed/s.frameTime)%len(s.frames)]
+}
+
+// Draw draws the sprite over dst with its top-left corner at pos, showing the
+// frame for the time elapsed since the animation began.
+func (s *Sprite) Draw(dst draw.Image, pos image.Point, elapsed time.Duration) {
+	frame := s.Frame(elapsed)
+	draw.Draw(dst, frame.Bounds().Add(pos), frame, image.Point{}, draw.Over)
+}
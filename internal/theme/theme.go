@@ -0,0 +1,368 @@
+// Package theme holds the colours the clock draws with, named for what they are used
+// for rather than what they look like, so pages can be recoloured as a whole. Themes
+// are YAML files: a handful are built in, and more can be loaded at runtime, each
+// changing as few or as many colours as it likes from the theme it is based on.
+package theme
+
+import (
+	"embed"
+	"fmt"
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/g-wilson/led/internal/huegradient"
+	"github.com/lucasb-eyer/go-colorful"
+	"gopkg.in/yaml.v3"
+)
+
+// Default is the theme every other theme is based on, unless it names another.
+const Default = "default"
+
+//go:embed themes/*.yaml
+var builtinThemes embed.FS
+
+// Theme is a named set of colours.
+type Theme struct {
+	Name string
+	// Tint, if set, recolours whole frames in shades of a single colour once they
+	// are drawn, catching images and any colours which aren't the theme's own.
+	Tint *color.RGBA
+
+	Colours
+	Palettes
+}
+
+// Colours are the single colours pages draw with.
+type Colours struct {
+	Text      color.RGBA // the time in the header, and other plain text
+	Heading   color.RGBA // page titles which don't stand out
+	Accent    color.RGBA // page titles which do, and other details to pick out
+	Highlight color.RGBA // the subject of a page, such as an event's name
+	Muted     color.RGBA // text with nothing much to say, such as labels
+	Alert     color.RGBA // things which need attention soon, such as a countdown
+	Label     color.RGBA // descriptions, such as the weather conditions
+
+	TempLow  color.RGBA
+	TempHigh color.RGBA
+
+	// Good, Fair, Warning and Bad rate readings, from best to worst.
+	Good    color.RGBA
+	Fair    color.RGBA
+	Warning color.RGBA
+	Bad     color.RGBA
+
+	// Notification colours, by priority.
+	Notification       color.RGBA
+	NotificationHigh   color.RGBA
+	NotificationUrgent color.RGBA
+
+	Dial        color.RGBA // the analog clock's rim
+	DialTick    color.RGBA
+	DialQuarter color.RGBA
+	SecondHand  color.RGBA
+
+	SecondsBar    color.RGBA // the big clock's seconds bar, filled and unfilled
+	SecondsBarOff color.RGBA
+
+	MoonLit  color.RGBA
+	MoonDark color.RGBA
+	MoonName color.RGBA
+
+	Track color.RGBA // the empty part of gauges
+}
+
+// Palettes are sequences of colours, for lists of things which should each look
+// different, and for charts.
+type Palettes struct {
+	Sensors Palette
+	Media   Palette
+	Sun     Palette
+	Moon    Palette
+	// Chart runs from cold or good at the bottom of a chart to hot or bad at the top.
+	Chart Palette
+}
+
+// Palette is a list of colours, or steps around the hue wheel from a starting hue.
+type Palette struct {
+	Colours []color.RGBA
+	Hue     *huegradient.Gradient
+}
+
+// Color returns the palette's colour for item i, repeating the list of colours if
+// there are more items than colours.
+func (p Palette) Color(i int) color.RGBA {
+	if p.Hue != nil {
+		return p.Hue.Color(i)
+	}
+	if len(p.Colours) == 0 {
+		return color.RGBA{255, 255, 255, 255}
+	}
+	return p.Colours[i%len(p.Colours)]
+}
+
+// Stops returns the palette's colours as stops for a chart's colour ramp, taking the
+// first n steps of palettes which step around the hue wheel.
+func (p Palette) Stops(n int) []color.RGBA {
+	if p.Hue == nil && len(p.Colours) > 0 {
+		return p.Colours
+	}
+	stops := make([]color.RGBA, n)
+	for i := range stops {
+		stops[i] = p.Color(i)
+	}
+	return stops
+}
+
+// UnmarshalYAML reads a palette as a list of colours, or as a mapping giving the hue
+// to start from and the step between colours, in degrees.
+func (p *Palette) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.MappingNode {
+		var g struct {
+			Hue  float64 `yaml:"hue"`
+			Step float64 `yaml:"step"`
+		}
+		if err := node.Decode(&g); err != nil {
+			return err
+		}
+		*p = Palette{Hue: &huegradient.Gradient{BaseHue: g.Hue, Step: g.Step}}
+		return nil
+	}
+
+	var hex []string
+	if err := node.Decode(&hex); err != nil {
+		return fmt.Errorf("line %d: palette must be a list of colours, or a hue and step", node.Line)
+	}
+	if len(hex) == 0 {
+		return fmt.Errorf("line %d: palette has no colours", node.Line)
+	}
+	colours := make([]color.RGBA, len(hex))
+	for i, h := range hex {
+		c, err := ParseColour(h)
+		if err != nil {
+			return err
+		}
+		colours[i] = c
+	}
+	*p = Palette{Colours: colours}
+	return nil
+}
+
+// ParseColour parses a hex colour, such as "#ff8800".
+func ParseColour(s string) (color.RGBA, error) {
+	c, err := colorful.Hex(s)
+	if err != nil {
+		return color.RGBA{}, fmt.Errorf("invalid colour %q", s)
+	}
+	r, g, b := c.RGB255()
+	return color.RGBA{r, g, b, 255}, nil
+}
+
+// Apply recolours the image with the theme's tint, keeping each pixel's brightness.
+// It does nothing for themes without a tint.
+func (t *Theme) Apply(img *image.RGBA) {
+	if t.Tint == nil {
+		return
+	}
+	tint := *t.Tint
+	for i := 0; i+3 < len(img.Pix); i += 4 {
+		v := uint32(max(img.Pix[i], img.Pix[i+1], img.Pix[i+2]))
+		img.Pix[i] = uint8(v * uint32(tint.R) / 255)
+		img.Pix[i+1] = uint8(v * uint32(tint.G) / 255)
+		img.Pix[i+2] = uint8(v * uint32(tint.B) / 255)
+	}
+}
+
+// Set is the themes available to switch between, by name.
+type Set struct {
+	themes map[string]*Theme
+}
+
+// Load reads the built in themes, then the theme files in order. Each file's theme is
+// named by its name field, or else after the file, e.g. dusk.yaml becomes dusk, and
+// replaces any theme already loaded with the same name.
+func Load(files []string) (*Set, error) {
+	s := &Set{themes: map[string]*Theme{}}
+
+	// the default theme comes first, as the others are based on it
+	if err := s.addBuiltin(Default + ".yaml"); err != nil {
+		return nil, err
+	}
+	builtin, err := builtinThemes.ReadDir("themes")
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range builtin {
+		if f.Name() == Default+".yaml" {
+			continue
+		}
+		if err := s.addBuiltin(f.Name()); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("theme: cannot read %q: %w", path, err)
+		}
+		if err := s.add(data, filepath.Base(path)); err != nil {
+			return nil, fmt.Errorf("theme: %q: %w", path, err)
+		}
+	}
+
+	return s, nil
+}
+
+// Get returns the theme with the given name.
+func (s *Set) Get(name string) (*Theme, bool) {
+	t, ok := s.themes[strings.ToLower(name)]
+	return t, ok
+}
+
+// Names lists the themes, in alphabetical order.
+func (s *Set) Names() []string {
+	names := make([]string, 0, len(s.themes))
+	for name := range s.themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (s *Set) addBuiltin(filename string) error {
+	data, err := builtinThemes.ReadFile("themes/" + filename)
+	if err != nil {
+		return err
+	}
+	if err := s.add(data, filename); err != nil {
+		return fmt.Errorf("theme: built in theme %q: %w", filename, err)
+	}
+	return nil
+}
+
+// themeFile is the YAML form of a theme. Colours and palettes are keyed by the names
+// of the fields in Colours and Palettes, in camel case: text, tempLow and so on.
+type themeFile struct {
+	Name     string             `yaml:"name"`
+	Base     string             `yaml:"base"`
+	Tint     string             `yaml:"tint"`
+	Colours  map[string]string  `yaml:"colours"`
+	Palettes map[string]Palette `yaml:"palettes"`
+}
+
+func (s *Set) add(data []byte, filename string) error {
+	var f themeFile
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return fmt.Errorf("invalid YAML: %w", err)
+	}
+
+	name := strings.ToLower(f.Name)
+	if name == "" {
+		name = strings.ToLower(strings.TrimSuffix(filename, filepath.Ext(filename)))
+	}
+
+	t := &Theme{}
+	if name != Default || f.Base != "" {
+		base := f.Base
+		if base == "" {
+			base = Default
+		}
+		b, ok := s.Get(base)
+		if !ok {
+			return fmt.Errorf("unknown base theme %q", base)
+		}
+		*t = *b
+	}
+	t.Name = name
+
+	if f.Tint != "" {
+		tint, err := ParseColour(f.Tint)
+		if err != nil {
+			return fmt.Errorf("tint: %w", err)
+		}
+		t.Tint = &tint
+	}
+
+	colours := t.Colours.byName()
+	for key, hex := range f.Colours {
+		c, ok := colours[key]
+		if !ok {
+			return fmt.Errorf("unknown colour %q", key)
+		}
+		var err error
+		if *c, err = ParseColour(hex); err != nil {
+			return fmt.Errorf("colour %q: %w", key, err)
+		}
+	}
+
+	palettes := t.Palettes.byName()
+	for key, p := range f.Palettes {
+		dst, ok := palettes[key]
+		if !ok {
+			return fmt.Errorf("unknown palette %q", key)
+		}
+		*dst = p
+	}
+
+	// a theme based on nothing has to give every colour itself
+	if name == Default && f.Base == "" {
+		for key, c := range colours {
+			if c.A == 0 {
+				return fmt.Errorf("missing colour %q", key)
+			}
+		}
+		for key, p := range palettes {
+			if p.Hue == nil && len(p.Colours) == 0 {
+				return fmt.Errorf("missing palette %q", key)
+			}
+		}
+	}
+
+	s.themes[name] = t
+	return nil
+}
+
+func (c *Colours) byName() map[string]*color.RGBA {
+	return map[string]*color.RGBA{
+		"text":               &c.Text,
+		"heading":            &c.Heading,
+		"accent":             &c.Accent,
+		"highlight":          &c.Highlight,
+		"muted":              &c.Muted,
+		"alert":              &c.Alert,
+		"label":              &c.Label,
+		"tempLow":            &c.TempLow,
+		"tempHigh":           &c.TempHigh,
+		"good":               &c.Good,
+		"fair":               &c.Fair,
+		"warning":            &c.Warning,
+		"bad":                &c.Bad,
+		"notification":       &c.Notification,
+		"notificationHigh":   &c.NotificationHigh,
+		"notificationUrgent": &c.NotificationUrgent,
+		"dial":               &c.Dial,
+		"dialTick":           &c.DialTick,
+		"dialQuarter":        &c.DialQuarter,
+		"secondHand":         &c.SecondHand,
+		"secondsBar":         &c.SecondsBar,
+		"secondsBarOff":      &c.SecondsBarOff,
+		"moonLit":            &c.MoonLit,
+		"moonDark":           &c.MoonDark,
+		"moonName":           &c.MoonName,
+		"track":              &c.Track,
+	}
+}
+
+func (p *Palettes) byName() map[string]*Palette {
+	return map[string]*Palette{
+		"sensors": &p.Sensors,
+		"media":   &p.Media,
+		"sun":     &p.Sun,
+		"moon":    &p.Moon,
+		"chart":   &p.Chart,
+	}
+}
@@ -36,16 +36,22 @@ func (c *Client) GetTwoDayWeatherAtLocation(ctx context.Context, lat, lon string
 		"location":  []string{fmt.Sprintf("%s,%s", lat, lon)},
 		"fields":    []string{"core"},
 		"units":     []string{"metric"},
-		"timesteps": []string{"1d"},
+		"timesteps": []string{"1h,1d"},
 		"apikey":    []string{c.apiKey},
 	})
 	if err != nil {
 		return weather.TwoDayWeather{}, err
 	}
 
+	hourly := make([]weather.HourWeather, len(resp.Timelines.Hourly))
+	for i, h := range resp.Timelines.Hourly {
+		hourly[i] = h.ToDomain()
+	}
+
 	return weather.TwoDayWeather{
 		Today:    resp.Timelines.Daily[0].Values.ToDomain(),
 		Tomorrow: resp.Timelines.Daily[1].Values.ToDomain(),
+		Hourly:   hourly,
 	}, nil
 }
 
@@ -117,8 +117,19 @@ type Daily struct {
 	Values Values    `json:"values"`
 }
 
+type Hourly struct {
+	Time   time.Time    `json:"time"`
+	Values HourlyValues `json:"values"`
+}
+
+// HourlyValues holds the fields of an hourly forecast the clock uses.
+type HourlyValues struct {
+	Temperature float64 `json:"temperature"`
+}
+
 type Timelines struct {
-	Daily []Daily `json:"daily"`
+	Hourly []Hourly `json:"hourly"`
+	Daily  []Daily  `json:"daily"`
 }
 
 type Location struct {
@@ -126,6 +137,13 @@ type Location struct {
 	Lon float64 `json:"lon"`
 }
 
+func (h Hourly) ToDomain() weather.HourWeather {
+	return weather.HourWeather{
+		Time:        h.Time,
+		Temperature: float32(h.Values.Temperature),
+	}
+}
+
 func (d Values) ToDomain() weather.DayWeather {
 	return weather.DayWeather{
 		TemperatureHigh: float32(d.TemperatureMax),
@@ -139,5 +157,6 @@ func (d Values) ToDomain() weather.DayWeather {
 		Cloudy:          d.CloudCoverAvg > 80,
 		Snowy:           d.SnowIntensityMax > 1.0,
 		Humidity:        float32(d.HumidityAvg),
+		WeatherCode:     int(d.WeatherCodeMax),
 	}
 }
@@ -22,11 +22,21 @@ type DayWeather struct {
 	Cloudy          bool
 	Snowy           bool
 	Humidity        float32
+	// WeatherCode is the Tomorrow.io weather code for the day's most significant
+	// weather, or zero if the provider does not give one.
+	WeatherCode int
+}
+
+// HourWeather is the forecast for the hour starting at Time.
+type HourWeather struct {
+	Time        time.Time
+	Temperature float32
 }
 
 type TwoDayWeather struct {
 	Today    DayWeather
 	Tomorrow DayWeather
+	Hourly   []HourWeather
 }
 
 type DayWeatherProvider interface {
@@ -41,6 +51,7 @@ type Agent struct {
 	mu           sync.RWMutex
 	todayData    DayWeather
 	tomorrowData DayWeather
+	hourlyData   []HourWeather
 }
 
 type AgentOptions struct {
@@ -95,6 +106,7 @@ func (a *Agent) populateCache() (err error) {
 
 	a.todayData = dw.Today
 	a.tomorrowData = dw.Tomorrow
+	a.hourlyData = dw.Hourly
 
 	return
 }
@@ -112,3 +124,17 @@ func (a *Agent) GetTomorrow() DayWeather {
 
 	return a.tomorrowData
 }
+
+// GetTemperatureAt returns the forecast temperature for the hour containing t, if
+// the hourly forecast covers it.
+func (a *Agent) GetTemperatureAt(t time.Time) (float32, bool) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	for _, h := range a.hourlyData {
+		if !t.Before(h.Time) && t.Before(h.Time.Add(time.Hour)) {
+			return h.Temperature, true
+		}
+	}
+	return 0, false
+}
@@ -49,6 +49,7 @@ type Renderer struct {
 	errorChan        <-chan error       // receive-only channel for framestreamer errors
 	projectionMatrix [16]float32
 	projectionDirty  bool
+	keyHandlers      map[glfw.Key]func()
 }
 
 // New creates and initializes a new window renderer.
@@ -68,6 +69,7 @@ func New(title string, ledRows, ledCols int, frameChan <-chan *image.RGBA, error
 		frameChan:       frameChan,
 		errorChan:       errorChan,
 		projectionDirty: true, // Initial projection calculation needed
+		keyHandlers:     map[glfw.Key]func(){},
 	}
 
 	// Configure GLFW window hints
@@ -104,9 +106,22 @@ func New(title string, ledRows, ledCols int, frameChan <-chan *image.RGBA, error
 	// Set up window resize callback
 	r.window.SetFramebufferSizeCallback(r.framebufferSizeCallback)
 
+	// Set up key callback
+	r.window.SetKeyCallback(r.keyCallback)
+
 	return r, nil
 }
 
+// OnKey calls fn on the main thread whenever the key is pressed in the window.
+func (r *Renderer) OnKey(key glfw.Key, fn func()) {
+	r.keyHandlers[key] = fn
+}
+
+// SetTitle changes the window title (must be called from main thread)
+func (r *Renderer) SetTitle(title string) {
+	r.window.SetTitle(title)
+}
+
 // Cleanup releases all OpenGL resources and destroys the window
 func (r *Renderer) Cleanup() {
 	r.cleanupOpenGL()
@@ -362,6 +377,15 @@ func (r *Renderer) framebufferSizeCallback(_ *glfw.Window, width, height int) {
 	r.projectionDirty = true
 }
 
+func (r *Renderer) keyCallback(_ *glfw.Window, key glfw.Key, _ int, action glfw.Action, _ glfw.ModifierKey) {
+	if action != glfw.Press {
+		return
+	}
+	if fn, ok := r.keyHandlers[key]; ok {
+		fn()
+	}
+}
+
 func (r *Renderer) cleanupOpenGL() {
 	gl.DeleteTextures(1, &r.texture)
 	gl.DeleteBuffers(1, &r.vbo)
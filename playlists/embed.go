@@ -0,0 +1,6 @@
+package playlists
+
+import _ "embed"
+
+//go:embed default.yaml
+var DefaultYAML []byte